@@ -0,0 +1,208 @@
+package reply
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// ArchiveFormat defines the format of an archive reply.
+type ArchiveFormat int
+
+const (
+	// ArchiveZip is a zip archive with deflated entries. It is the default.
+	ArchiveZip ArchiveFormat = iota + 1
+
+	// ArchiveTarGzip is a gzip compressed tar archive.
+	ArchiveTarGzip
+)
+
+// ArchiveEntry is a file of an archive reply.
+type ArchiveEntry struct {
+	// Name is the slash separated path of the file in the archive.
+	Name string
+
+	// ModTime is the modification time of the file.
+	ModTime time.Time
+
+	// Body is the content of the file. If Body is an io.Closer, it is closed
+	// once written.
+	Body io.Reader
+
+	// Size is the length of Body. Tar archives require the length of each
+	// file up front, so if Size is zero, the Body of a tar entry is buffered
+	// in memory to find it.
+	Size int64
+}
+
+// ArchiveIterator iterates the entries of an archive reply. Next returns the
+// next entry, or io.EOF if no entries remain.
+type ArchiveIterator interface {
+	Next() (ArchiveEntry, error)
+}
+
+// ArchiveFunc is an adapter to allow the use of an ordinary function as an
+// ArchiveIterator.
+type ArchiveFunc func() (ArchiveEntry, error)
+
+// Next calls f().
+func (f ArchiveFunc) Next() (ArchiveEntry, error) {
+	return f()
+}
+
+// ArchiveEntries returns an ArchiveIterator of entries.
+func ArchiveEntries(entries ...ArchiveEntry) ArchiveIterator {
+	return ArchiveFunc(func() (ArchiveEntry, error) {
+		if len(entries) == 0 {
+			return ArchiveEntry{}, io.EOF
+		}
+		entry := entries[0]
+		entries = entries[1:]
+		return entry, nil
+	})
+}
+
+// Archive replies with HTTP Status 200 OK and an archive of entries in the
+// given format, as an attachment with name as its suggested filename. The
+// archive is streamed to w as entries are read, without Content-Length.
+//
+// If an error is encountered before the first byte of the archive is sent,
+// Archive replies with an error through e's Writer like ReplyOrError and
+// returns the error. Afterwards, the archive is left truncated and Archive
+// returns a *StreamError.
+func (e Engine) Archive(w http.ResponseWriter, name string, format ArchiveFormat, entries ArchiveIterator) error {
+	aw := &archiveWriter{w: w, contentType: "application/zip", name: name}
+	write := writeZip
+	if format == ArchiveTarGzip {
+		aw.contentType, write = "application/gzip", writeTarGzip
+	}
+	err := write(aw, entries)
+	if err == nil {
+		return nil
+	}
+	if aw.started {
+		return &StreamError{Err: err}
+	}
+	if !e.Debug {
+		e.InternalServerError(w)
+	} else {
+		e.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+	return err
+}
+
+// archiveWriter sends the reply header of an archive on its first write.
+type archiveWriter struct {
+	w           http.ResponseWriter
+	contentType string
+	name        string
+	started     bool
+}
+
+func (aw *archiveWriter) Write(p []byte) (int, error) {
+	if !aw.started {
+		aw.started = true
+		h := aw.w.Header()
+		h.Set("Content-Type", aw.contentType)
+		h.Set("Content-Disposition", contentDisposition("attachment", aw.name))
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Del("Content-Length")
+		aw.w.WriteHeader(http.StatusOK)
+	}
+	return aw.w.Write(p)
+}
+
+// writeZip writes a zip archive of entries to w.
+func writeZip(w io.Writer, entries ArchiveIterator) error {
+	zw := zip.NewWriter(w)
+	err := eachEntry(entries, func(entry ArchiveEntry) error {
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     entry.Name,
+			Modified: entry.ModTime,
+			Method:   zip.Deflate,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, entry.Body)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// writeTarGzip writes a gzip compressed tar archive of entries to w. Output
+// is buffered so that early errors are not preceded by the gzip header.
+func writeTarGzip(w io.Writer, entries ArchiveIterator) error {
+	bw := bufio.NewWriter(w)
+	gw := gzip.NewWriter(bw)
+	tw := tar.NewWriter(gw)
+	err := eachEntry(entries, func(entry ArchiveEntry) error {
+		body, size := entry.Body, entry.Size
+		if size == 0 {
+			buf := getBuffer()
+			defer putBuffer(buf)
+			if _, err := buf.ReadFrom(body); err != nil {
+				return err
+			}
+			body, size = bytes.NewReader(buf.Bytes()), int64(buf.Len())
+		}
+		err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     entry.Name,
+			ModTime:  entry.ModTime,
+			Mode:     0o644,
+			Size:     size,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(tw, body)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// eachEntry calls write with each entry of entries, with its name cleaned of
+// leading slashes and parent directories and with a non-nil Body, closing the
+// Body afterwards if it is an io.Closer.
+func eachEntry(entries ArchiveIterator, write func(ArchiveEntry) error) error {
+	for {
+		entry, err := entries.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		entry.Name = strings.TrimPrefix(path.Clean("/"+entry.Name), "/")
+		if entry.Body == nil {
+			entry.Body = strings.NewReader("")
+		}
+		err = write(entry)
+		if c, ok := entry.Body.(io.Closer); ok {
+			c.Close()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package reply
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readArchive returns the names and contents of the files in an archive.
+func readArchive(t *testing.T, format ArchiveFormat, b []byte) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	switch format {
+	case ArchiveTarGzip:
+		gr, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr := tar.NewReader(gr)
+		for {
+			h, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(tr)
+			files[h.Name] = string(body)
+		}
+	default:
+		zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range zr.File {
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(rc)
+			rc.Close()
+			files[f.Name] = string(body)
+		}
+	}
+	return files
+}
+
+// failingReader returns n random bytes and then an error.
+type failingReader struct {
+	n int
+}
+
+func (fr *failingReader) Read(p []byte) (int, error) {
+	if fr.n == 0 {
+		return 0, errors.New("disk unavailable")
+	}
+	n := min(len(p), fr.n)
+	_, _ = rand.Read(p[:n])
+	fr.n -= n
+	return n, nil
+}
+
+func TestArchive(t *testing.T) {
+	modtime := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	entries := func() ArchiveIterator {
+		return ArchiveEntries(
+			ArchiveEntry{Name: "a.txt", ModTime: modtime, Body: strings.NewReader("alpha")},
+			ArchiveEntry{Name: "/docs/../../b.txt", ModTime: modtime, Body: strings.NewReader("bravo"), Size: 5},
+			ArchiveEntry{Name: "empty.txt", ModTime: modtime},
+		)
+	}
+	wantFiles := map[string]string{"a.txt": "alpha", "b.txt": "bravo", "empty.txt": ""}
+	cases := map[string]struct {
+		engine    Engine
+		format    ArchiveFormat
+		entries   ArchiveIterator
+		wantErr   error
+		wantCode  int
+		wantType  string
+		wantFiles map[string]string
+		wantBody  string
+	}{
+		"zip": {
+			format:    ArchiveZip,
+			entries:   entries(),
+			wantCode:  http.StatusOK,
+			wantType:  "application/zip",
+			wantFiles: wantFiles,
+		},
+		"tar.gz": {
+			format:    ArchiveTarGzip,
+			entries:   entries(),
+			wantCode:  http.StatusOK,
+			wantType:  "application/gzip",
+			wantFiles: wantFiles,
+		},
+		"iterator error": {
+			format: ArchiveZip,
+			entries: ArchiveFunc(func() (ArchiveEntry, error) {
+				return ArchiveEntry{}, errors.New("query failed")
+			}),
+			wantErr:  errors.New("query failed"),
+			wantCode: http.StatusInternalServerError,
+			wantType: "application/json",
+			wantBody: `{"error":"Internal Server Error"}`,
+		},
+		"iterator error; debug": {
+			engine: Engine{Debug: true},
+			format: ArchiveTarGzip,
+			entries: ArchiveFunc(func() (ArchiveEntry, error) {
+				return ArchiveEntry{}, errors.New("query failed")
+			}),
+			wantErr:  errors.New("query failed"),
+			wantCode: http.StatusInternalServerError,
+			wantType: "application/json",
+			wantBody: `{"error":"query failed"}`,
+		},
+		"body error before first byte": {
+			format:   ArchiveTarGzip,
+			entries:  ArchiveEntries(ArchiveEntry{Name: "a.txt", Body: &failingReader{n: 10}, Size: 20}),
+			wantErr:  errors.New("disk unavailable"),
+			wantCode: http.StatusInternalServerError,
+			wantType: "application/json",
+			wantBody: `{"error":"Internal Server Error"}`,
+		},
+		"body error after first byte": {
+			format:   ArchiveZip,
+			entries:  ArchiveEntries(ArchiveEntry{Name: "a.txt", Body: &failingReader{n: 1 << 20}}),
+			wantErr:  &StreamError{Err: errors.New("disk unavailable")},
+			wantCode: http.StatusOK,
+			wantType: "application/zip",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			c.engine.Writer = JSONWriter{}
+			w := httptest.NewRecorder()
+			err := c.engine.Archive(w, "export.zip", c.format, c.entries)
+			if (err == nil) != (c.wantErr == nil) || err != nil && err.Error() != c.wantErr.Error() {
+				t.Errorf(errorString, err, c.wantErr)
+			}
+			if got := w.Code; got != c.wantCode {
+				t.Errorf(errorString, got, c.wantCode)
+			}
+			if got := w.Header().Get("Content-Type"); got != c.wantType {
+				t.Errorf(errorString, got, c.wantType)
+			}
+			if c.wantFiles == nil {
+				if got := strings.TrimSpace(w.Body.String()); c.wantBody != "" && got != c.wantBody {
+					t.Errorf(errorString, got, c.wantBody)
+				}
+				return
+			}
+			if got, want := w.Header().Get("Content-Disposition"), `attachment; filename="export.zip"`; got != want {
+				t.Errorf(errorString, got, want)
+			}
+			got := readArchive(t, c.format, w.Body.Bytes())
+			if len(got) != len(c.wantFiles) {
+				t.Errorf(errorString, got, c.wantFiles)
+			}
+			for name, want := range c.wantFiles {
+				if got[name] != want {
+					t.Errorf(errorString, got[name], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package reply
+
+import (
+	"bytes"
+	"encoding/hex"
+	"hash"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Body configures how a buffering Writer sends the replies it has rendered.
+// It is embedded by JSONWriter and TemplateWriter, and so by the writers
+// built on them.
+type Body struct {
+	// ETag defines an optional entity tag mode. If set, 200 OK replies carry
+	// an ETag of their body and are answered with 304 Not Modified when it
+	// matches the request's If-None-Match.
+	ETag *ETag
+
+	// Compression defines optional compression of reply bodies. If set,
+	// compressible replies are compressed with the encoder negotiated
+	// through the request's Accept-Encoding and carry Vary: Accept-Encoding.
+	Compression *Compression
+}
+
+// ETag configures the entity tags generated for reply bodies.
+type ETag struct {
+	// Weak defines whether generated tags are weak validators.
+	Weak bool
+
+	// Hash defines the hash function of tags. If nil, 128-bit FNV-1a is used.
+	Hash func() hash.Hash
+}
+
+// Tag returns the entity tag of body.
+func (et *ETag) Tag(body []byte) string {
+	h := et.hash()
+	_, _ = h.Write(body)
+	return et.tag(h.Sum(nil))
+}
+
+// hash returns a new hash of et's hash function.
+func (et *ETag) hash() hash.Hash {
+	if et.Hash == nil {
+		return fnv.New128a()
+	}
+	return et.Hash()
+}
+
+// tag returns the entity tag of a body with the given hash sum.
+func (et *ETag) tag(sum []byte) string {
+	tag := `"` + hex.EncodeToString(sum) + `"`
+	if et.Weak {
+		return "W/" + tag
+	}
+	return tag
+}
+
+// send sends an HTTP status response header with the given status code and
+// writes buf to w, compressed if negotiated with opts.Request. A 200 OK reply
+// is given its validators, the ETag of the body sent and opts.LastModified,
+// and is sent as 304 Not Modified without a body if they satisfy the
+// conditional headers of opts.Request. The Content-Length of the body sent is
+// set on replies that allow a body, including replies to HEAD requests, which
+// are sent without it.
+func (b Body) send(w http.ResponseWriter, code int, buf *bytes.Buffer, opts Options) {
+	h := w.Header()
+	if b.Compression != nil && h.Get("Content-Encoding") == "" &&
+		b.Compression.compressible(code, h.Get("Content-Type")) {
+		addVary(h, "Accept-Encoding")
+		var coding string
+		if coding, buf = b.Compression.compress(opts.Request, buf); coding != "" {
+			defer putBuffer(buf)
+			h.Set("Content-Encoding", coding)
+		}
+	}
+	if code == http.StatusOK && b.ETag != nil {
+		h.Set("ETag", b.ETag.Tag(buf.Bytes()))
+	}
+	if sendNotModified(w, code, opts) {
+		return
+	}
+	if code >= http.StatusOK && code != http.StatusNoContent {
+		h.Set("Content-Length", strconv.Itoa(buf.Len()))
+	}
+	w.WriteHeader(code)
+	if opts.Request == nil || opts.Request.Method != http.MethodHead {
+		_, _ = buf.WriteTo(w)
+	}
+}
+
+// sendHeaders sends an HTTP status response header with the given status code
+// without a body, for replies that skip rendering. A 200 OK reply is sent as
+// 304 Not Modified if opts.LastModified satisfies the conditional headers of
+// opts.Request.
+func (b Body) sendHeaders(w http.ResponseWriter, code int, opts Options) {
+	if !sendNotModified(w, code, opts) {
+		w.WriteHeader(code)
+	}
+}
+
+// skipBody reports whether a reply using opts skips rendering its body.
+func skipBody(opts Options) bool {
+	return opts.HeadersOnly && opts.Request != nil && opts.Request.Method == http.MethodHead
+}
+
+// sendNotModified sets the Last-Modified header of a 200 OK reply and, if its
+// validators satisfy the conditional headers of opts.Request, sends 304 Not
+// Modified and reports true.
+func sendNotModified(w http.ResponseWriter, code int, opts Options) bool {
+	if code != http.StatusOK {
+		return false
+	}
+	h := w.Header()
+	if !opts.LastModified.IsZero() {
+		h.Set("Last-Modified", opts.LastModified.UTC().Format(http.TimeFormat))
+	}
+	if !notModified(opts.Request, h.Get("ETag"), opts.LastModified) {
+		return false
+	}
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+	h.Del("Content-Encoding")
+	if h.Get("ETag") != "" {
+		h.Del("Last-Modified")
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// notModified reports whether r, a GET or HEAD request, is satisfied by a
+// representation with the given etag and modtime. If-None-Match takes
+// precedence over If-Modified-Since.
+func notModified(r *http.Request, etag string, modtime time.Time) bool {
+	if r == nil || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		return false
+	}
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etag != "" && etagMatch(inm, etag, true)
+	}
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || modtime.IsZero() {
+		return false
+	}
+	t, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	return !modtime.Truncate(time.Second).After(t)
+}
+
+// etagMatch reports whether etag matches an entry of list, a comma separated
+// If-Match or If-None-Match header value. Weak comparison ignores the weak
+// indicator of both tags; strong comparison never matches weak tags.
+func etagMatch(list, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		} else if candidate == etag && !strings.HasPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
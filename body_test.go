@@ -0,0 +1,356 @@
+package reply
+
+import (
+	"crypto/sha256"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestETagTag(t *testing.T) {
+	body := []byte(`{"foo":"bar"}`)
+	cases := map[string]struct {
+		etag *ETag
+		want string
+	}{
+		"strong": {
+			etag: &ETag{},
+			want: `"0ebb48919b97b3fd5bfd7db50f959532"`,
+		},
+		"weak": {
+			etag: &ETag{Weak: true},
+			want: `W/"0ebb48919b97b3fd5bfd7db50f959532"`,
+		},
+		"custom hash": {
+			etag: &ETag{Hash: sha256.New},
+			want: `"7a38bf81f383f69433ad6e900d35b3e2385593f76a7b7ab5d4355b8ba41ee24b"`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := c.etag.Tag(body); got != c.want {
+				t.Errorf(errorString, got, c.want)
+			}
+		})
+	}
+}
+
+func TestConditionalGet(t *testing.T) {
+	modtime := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	etag := (&ETag{}).Tag([]byte(`{"foo":"bar"}` + "\n"))
+	jw := JSONWriter{Body: Body{ETag: &ETag{}}}
+	tw := NewTemplateWriter(map[string]*template.Template{"quux": quux})
+	cases := map[string]struct {
+		writer       Writer
+		method       string
+		code         int
+		header       map[string]string
+		lastModified time.Time
+		wantCode     int
+		wantETag     string
+		wantBody     string
+	}{
+		"no conditional headers": {
+			writer:   jw,
+			code:     http.StatusOK,
+			wantCode: http.StatusOK,
+			wantETag: etag,
+			wantBody: `{"foo":"bar"}`,
+		},
+		"if-none-match matches": {
+			writer:   jw,
+			code:     http.StatusOK,
+			header:   map[string]string{"If-None-Match": `"other", ` + etag},
+			wantCode: http.StatusNotModified,
+			wantETag: etag,
+		},
+		"if-none-match matches weakly": {
+			writer:   jw,
+			code:     http.StatusOK,
+			header:   map[string]string{"If-None-Match": "W/" + etag},
+			wantCode: http.StatusNotModified,
+			wantETag: etag,
+		},
+		"if-none-match star": {
+			writer:   jw,
+			method:   http.MethodHead,
+			code:     http.StatusOK,
+			header:   map[string]string{"If-None-Match": "*"},
+			wantCode: http.StatusNotModified,
+			wantETag: etag,
+		},
+		"if-none-match differs": {
+			writer:   jw,
+			code:     http.StatusOK,
+			header:   map[string]string{"If-None-Match": `"other"`},
+			wantCode: http.StatusOK,
+			wantETag: etag,
+			wantBody: `{"foo":"bar"}`,
+		},
+		"if-none-match; not GET": {
+			writer:   jw,
+			method:   http.MethodPost,
+			code:     http.StatusOK,
+			header:   map[string]string{"If-None-Match": etag},
+			wantCode: http.StatusOK,
+			wantETag: etag,
+			wantBody: `{"foo":"bar"}`,
+		},
+		"if-none-match; not 200": {
+			writer:   jw,
+			code:     http.StatusCreated,
+			header:   map[string]string{"If-None-Match": etag},
+			wantCode: http.StatusCreated,
+			wantBody: `{"foo":"bar"}`,
+		},
+		"if-none-match takes precedence": {
+			writer:       jw,
+			code:         http.StatusOK,
+			header:       map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": modtime.Format(http.TimeFormat)},
+			lastModified: modtime,
+			wantCode:     http.StatusOK,
+			wantETag:     etag,
+			wantBody:     `{"foo":"bar"}`,
+		},
+		"if-modified-since; not modified": {
+			writer:       tw,
+			code:         http.StatusOK,
+			header:       map[string]string{"If-Modified-Since": modtime.Format(http.TimeFormat)},
+			lastModified: modtime.Add(time.Millisecond),
+			wantCode:     http.StatusNotModified,
+		},
+		"if-modified-since; modified": {
+			writer:       tw,
+			code:         http.StatusOK,
+			header:       map[string]string{"If-Modified-Since": modtime.Add(-time.Hour).Format(http.TimeFormat)},
+			lastModified: modtime,
+			wantCode:     http.StatusOK,
+			wantBody:     "HELLO",
+		},
+		"if-modified-since; invalid": {
+			writer:       tw,
+			code:         http.StatusOK,
+			header:       map[string]string{"If-Modified-Since": "yesterday"},
+			lastModified: modtime,
+			wantCode:     http.StatusOK,
+			wantBody:     "HELLO",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			method := c.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, "/", nil)
+			for k, v := range c.header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			err := c.writer.Reply(w, c.code, Options{
+				TemplateKey:  "quux",
+				Data:         map[string]string{"foo": "bar"},
+				Request:      r,
+				LastModified: c.lastModified,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Code; got != c.wantCode {
+				t.Errorf(errorString, got, c.wantCode)
+			}
+			if got := w.Header().Get("ETag"); got != c.wantETag {
+				t.Errorf(errorString, got, c.wantETag)
+			}
+			if c.wantCode == http.StatusNotModified && w.Header().Get("Content-Type") != "" {
+				t.Errorf("unwanted Content-Type in 304 reply")
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != c.wantBody {
+				t.Errorf(errorString, got, c.wantBody)
+			}
+		})
+	}
+}
+
+func TestHead(t *testing.T) {
+	modtime := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	jw := JSONWriter{Body: Body{ETag: &ETag{}}}
+	tw := NewTemplateWriter(map[string]*template.Template{"foo": foo})
+	tw.ETag = &ETag{}
+	cases := map[string]struct {
+		writer       Writer
+		opts         Options
+		header       map[string]string
+		wantCode     int
+		wantLength   string
+		wantETag     bool
+		wantModified string
+	}{
+		"jw": {
+			writer:     jw,
+			opts:       Options{Data: map[string]string{"foo": "bar"}},
+			wantCode:   http.StatusOK,
+			wantLength: "14",
+			wantETag:   true,
+		},
+		"tw": {
+			writer:     tw,
+			opts:       Options{TemplateKey: "foo", TemplateName: "base", Data: struct{ Name string }{"Sherlock"}},
+			wantCode:   http.StatusOK,
+			wantLength: "15",
+			wantETag:   true,
+		},
+		"jw; headers only": {
+			writer:       jw,
+			opts:         Options{Data: make(chan int), HeadersOnly: true, LastModified: modtime},
+			wantCode:     http.StatusOK,
+			wantModified: modtime.Format(http.TimeFormat),
+		},
+		"tw; headers only": {
+			writer:   tw,
+			opts:     Options{TemplateKey: "foo", TemplateName: "bass", HeadersOnly: true},
+			wantCode: http.StatusOK,
+		},
+		"tw; headers only; not modified": {
+			writer:       tw,
+			opts:         Options{TemplateKey: "foo", HeadersOnly: true, LastModified: modtime},
+			header:       map[string]string{"If-Modified-Since": modtime.Format(http.TimeFormat)},
+			wantCode:     http.StatusNotModified,
+			wantModified: modtime.Format(http.TimeFormat),
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodHead, "/", nil)
+			for k, v := range c.header {
+				r.Header.Set(k, v)
+			}
+			c.opts.Request = r
+			w := httptest.NewRecorder()
+			if err := c.writer.Reply(w, http.StatusOK, c.opts); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Code; got != c.wantCode {
+				t.Errorf(errorString, got, c.wantCode)
+			}
+			if got := w.Header().Get("Content-Length"); got != c.wantLength {
+				t.Errorf(errorString, got, c.wantLength)
+			}
+			if got := w.Header().Get("ETag") != ""; got != c.wantETag {
+				t.Errorf(errorString, got, c.wantETag)
+			}
+			if got := w.Header().Get("Last-Modified"); got != c.wantModified {
+				t.Errorf(errorString, got, c.wantModified)
+			}
+			if got := w.Body.Len(); got != 0 {
+				t.Errorf(errorString, got, 0)
+			}
+		})
+	}
+}
+
+func TestContentLength(t *testing.T) {
+	large := strings.Repeat("Elementary, my dear Watson. ", 1000)
+	page := template.Must(template.New("page").Parse(`<p>{{.}}</p>`))
+	tw := NewTemplateWriter(map[string]*template.Template{"page": page})
+	ctw := NewTemplateWriter(map[string]*template.Template{"page": page})
+	ctw.Compression = &Compression{}
+	cases := map[string]struct {
+		reply    func(w http.ResponseWriter, r *http.Request)
+		method   string
+		wantCode int
+	}{
+		"jw": {
+			reply: func(w http.ResponseWriter, r *http.Request) {
+				Engine{Writer: JSONWriter{}}.OK(w, Options{Data: large, Request: r})
+			},
+			wantCode: http.StatusOK,
+		},
+		"jw; error": {
+			reply: func(w http.ResponseWriter, r *http.Request) {
+				Engine{Writer: JSONWriter{}}.NotFound(w)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		"jw; compressed": {
+			reply: func(w http.ResponseWriter, r *http.Request) {
+				jw := JSONWriter{Body: Body{Compression: &Compression{}}}
+				Engine{Writer: jw}.OK(w, Options{Data: large, Request: r})
+			},
+			wantCode: http.StatusOK,
+		},
+		"tw": {
+			reply: func(w http.ResponseWriter, r *http.Request) {
+				Engine{Writer: tw}.OK(w, Options{TemplateKey: "page", Data: large, Request: r})
+			},
+			wantCode: http.StatusOK,
+		},
+		"tw; error": {
+			reply: func(w http.ResponseWriter, r *http.Request) {
+				Engine{Writer: tw}.InternalServerError(w)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		"tw; compressed": {
+			reply: func(w http.ResponseWriter, r *http.Request) {
+				Engine{Writer: ctw}.OK(w, Options{TemplateKey: "page", Data: large, Request: r})
+			},
+			wantCode: http.StatusOK,
+		},
+		"tw; compressed; head": {
+			reply: func(w http.ResponseWriter, r *http.Request) {
+				Engine{Writer: ctw}.OK(w, Options{TemplateKey: "page", Data: large, Request: r})
+			},
+			method:   http.MethodHead,
+			wantCode: http.StatusOK,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var want string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				rec := httptest.NewRecorder()
+				get := r.Clone(r.Context())
+				get.Method = http.MethodGet
+				c.reply(rec, get)
+				want = strconv.Itoa(rec.Body.Len())
+				c.reply(w, r)
+			}))
+			defer srv.Close()
+			method := c.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req, _ := http.NewRequest(method, srv.URL, nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			res, err := srv.Client().Transport.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := res.StatusCode; got != c.wantCode {
+				t.Errorf(errorString, got, c.wantCode)
+			}
+			if got := res.Header.Get("Content-Length"); got != want {
+				t.Errorf(errorString, got, want)
+			}
+			if method != http.MethodHead {
+				if got := strconv.Itoa(len(body)); got != want {
+					t.Errorf(errorString, got, want)
+				}
+			}
+			if got := res.TransferEncoding; len(got) != 0 {
+				t.Errorf(errorString, got, nil)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package reply
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CacheControl represents a Cache-Control response policy. Durations are sent
+// in whole seconds and omitted if zero. Like http.Cookie's MaxAge, a negative
+// duration, such as ZeroAge, is sent as zero. The common policy of HTML pages,
+// "private, max-age=0", is
+//
+//	CacheControl{Private: true, MaxAge: ZeroAge}
+type CacheControl struct {
+	Public         bool
+	Private        bool
+	NoCache        bool
+	NoStore        bool
+	NoTransform    bool
+	MustRevalidate bool
+	Immutable      bool
+
+	MaxAge               time.Duration
+	SMaxAge              time.Duration
+	StaleWhileRevalidate time.Duration
+	StaleIfError         time.Duration
+}
+
+// ZeroAge is a duration of a CacheControl sent as zero, as in "max-age=0",
+// since zero durations are omitted.
+const ZeroAge time.Duration = -1
+
+// CachePolicy defines the default Cache-Control policies of an Engine.
+type CachePolicy struct {
+	// Replies defines the policy of replies without Options.Cache.
+	Replies *CacheControl
+
+	// Errors defines the policy of error replies, replacing any policy set
+	// before the error.
+	Errors *CacheControl
+}
+
+// String returns the Cache-Control header value of cc.
+func (cc CacheControl) String() string {
+	directives := []string{}
+	for _, d := range []struct {
+		on   bool
+		name string
+	}{
+		{cc.Public, "public"},
+		{cc.Private, "private"},
+		{cc.NoCache, "no-cache"},
+		{cc.NoStore, "no-store"},
+		{cc.NoTransform, "no-transform"},
+		{cc.MustRevalidate, "must-revalidate"},
+	} {
+		if d.on {
+			directives = append(directives, d.name)
+		}
+	}
+	for _, d := range []struct {
+		age  time.Duration
+		name string
+	}{
+		{cc.MaxAge, "max-age"},
+		{cc.SMaxAge, "s-maxage"},
+		{cc.StaleWhileRevalidate, "stale-while-revalidate"},
+		{cc.StaleIfError, "stale-if-error"},
+	} {
+		if d.age != 0 {
+			seconds := max(int64(d.age/time.Second), 0)
+			directives = append(directives, d.name+"="+strconv.FormatInt(seconds, 10))
+		}
+	}
+	if cc.Immutable {
+		directives = append(directives, "immutable")
+	}
+	return strings.Join(directives, ", ")
+}
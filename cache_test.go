@@ -0,0 +1,106 @@
+package reply
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCacheControlString(t *testing.T) {
+	cases := map[string]struct {
+		cc   CacheControl
+		want string
+	}{
+		"empty":     {cc: CacheControl{}, want: ""},
+		"no-store":  {cc: CacheControl{NoStore: true}, want: "no-store"},
+		"max-age=0": {cc: CacheControl{Private: true, MaxAge: ZeroAge}, want: "private, max-age=0"},
+		"negative":  {cc: CacheControl{NoCache: true, SMaxAge: -time.Hour}, want: "no-cache, s-maxage=0"},
+		"all": {
+			cc: CacheControl{
+				Public:               true,
+				NoTransform:          true,
+				MustRevalidate:       true,
+				Immutable:            true,
+				MaxAge:               time.Hour,
+				SMaxAge:              90 * time.Second,
+				StaleWhileRevalidate: time.Minute,
+				StaleIfError:         1500 * time.Millisecond,
+			},
+			want: "public, no-transform, must-revalidate, max-age=3600, s-maxage=90, stale-while-revalidate=60, stale-if-error=1, immutable",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := c.cc.String(); got != c.want {
+				t.Errorf(errorString, got, c.want)
+			}
+		})
+	}
+}
+
+func TestCachePolicy(t *testing.T) {
+	policy := CachePolicy{
+		Replies: &CacheControl{Private: true, MaxAge: ZeroAge},
+		Errors:  &CacheControl{NoStore: true},
+	}
+	rtw := Engine{Writer: NewTemplateWriter(map[string]*template.Template{"foo": foo}), Cache: policy}
+	cases := map[string]struct {
+		reply  Engine
+		prev   string
+		method func(Engine, http.ResponseWriter)
+		want   string
+	}{
+		"reply default": {
+			reply:  rtw,
+			method: func(e Engine, w http.ResponseWriter) { e.OK(w, Options{TemplateKey: "foo"}) },
+			want:   "private, max-age=0",
+		},
+		"reply override": {
+			reply: rtw,
+			method: func(e Engine, w http.ResponseWriter) {
+				e.OK(w, Options{TemplateKey: "foo", Cache: &CacheControl{Public: true, MaxAge: time.Minute}})
+			},
+			want: "public, max-age=60",
+		},
+		"error": {
+			reply:  rtw,
+			prev:   "public, max-age=60",
+			method: func(e Engine, w http.ResponseWriter) { e.NotFound(w) },
+			want:   "no-store",
+		},
+		"failed reply uses error policy": {
+			reply: rtw,
+			method: func(e Engine, w http.ResponseWriter) {
+				e.OK(w, Options{TemplateKey: "missing", Cache: &CacheControl{Public: true, MaxAge: time.Minute}})
+			},
+			want: "no-store",
+		},
+		"failed reply without error policy restores header": {
+			reply: Engine{Writer: JSONWriter{}, Cache: CachePolicy{Replies: policy.Replies}},
+			prev:  "no-cache",
+			method: func(e Engine, w http.ResponseWriter) {
+				e.OK(w, Options{Data: make(chan int)})
+			},
+			want: "no-cache",
+		},
+		"no policy": {
+			reply:  Engine{Writer: JSONWriter{}},
+			method: func(e Engine, w http.ResponseWriter) { e.OK(w, Options{}) },
+			want:   "",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if c.prev != "" {
+				w.Header().Set("Cache-Control", c.prev)
+			}
+			c.method(c.reply, w)
+			if got := w.Header().Get("Cache-Control"); got != c.want {
+				t.Errorf(errorString, got, c.want)
+			}
+		})
+	}
+}
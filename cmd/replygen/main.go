@@ -0,0 +1,267 @@
+// Command replygen generates Go constants for the template keys and defined
+// template names of a template directory, scanned as reply.ParseTemplates
+// does, so that typos fail to compile rather than reply with an error.
+//
+// Usage:
+//
+//	//go:generate go run github.com/novrin/reply/cmd/replygen -dir html -pages "pages/*.html" -shared "base.html,partials/*.html"
+//
+// With -render, it also generates a typed render helper for each page, such
+// as RenderUserShow(w http.ResponseWriter, data UserShowData) for the page
+// "user_show.html", which replies with the Engine variable named by -engine.
+// The data types are declared by the package. Data types of pages whose names
+// start with a digit are prefixed with Page, as in Page404Data for "404.html".
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"html/template"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/template/parse"
+	"unicode"
+
+	"github.com/novrin/reply"
+)
+
+// config defines what is generated.
+type config struct {
+	reply.TemplateConfig
+
+	// pkg is the package name of the generated file.
+	pkg string
+
+	// render defines whether render helpers are generated, replying with the
+	// Engine variable engine and executing the template name, if not empty.
+	render bool
+	engine string
+	name   string
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("replygen: ")
+	var (
+		cfg    config
+		dir    = flag.String("dir", ".", "directory of the template files")
+		shared = flag.String("shared", "", "comma separated globs of files shared by every page")
+		out    = flag.String("o", "templates_gen.go", "output file")
+	)
+	flag.StringVar(&cfg.Pages, "pages", "*.html", "glob of page files")
+	flag.BoolVar(&cfg.Recursive, "recursive", false, "walk the directory of -pages recursively")
+	flag.StringVar(&cfg.pkg, "pkg", os.Getenv("GOPACKAGE"), "package name of the output file")
+	flag.BoolVar(&cfg.render, "render", false, "generate typed render helpers")
+	flag.StringVar(&cfg.engine, "engine", "engine", "Engine variable used by render helpers")
+	flag.StringVar(&cfg.name, "name", "", "template name executed by render helpers")
+	flag.Parse()
+	if *shared != "" {
+		cfg.Shared = strings.Split(*shared, ",")
+	}
+	if cfg.pkg == "" {
+		cfg.pkg = "main"
+	}
+	src, err := generate(os.DirFS(*dir), cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate returns the formatted Go source of the constants and helpers of
+// the templates in fsys.
+func generate(fsys fs.FS, cfg config) ([]byte, error) {
+	funcs, err := funcStubs(fsys)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Funcs = funcs
+	templates, err := reply.ParseTemplates(fsys, cfg.TemplateConfig)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(templates))
+	names := map[string]bool{}
+	for key, tmpl := range templates {
+		keys = append(keys, key)
+		for _, t := range tmpl.Templates() {
+			if t.Name() != "" {
+				names[t.Name()] = true
+			}
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		delete(names, key)
+	}
+	keyIdents, err := identifiers("Key", keys, true)
+	if err != nil {
+		return nil, err
+	}
+	nameIdents, err := identifiers("Name", sortedNames(names), false)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by replygen; DO NOT EDIT.\n\npackage %s\n\n", cfg.pkg)
+	if cfg.render {
+		fmt.Fprintf(&b, "import (\n\"net/http\"\n\n\"github.com/novrin/reply\"\n)\n\n")
+	}
+	writeConsts(&b, "Template keys.", keyIdents)
+	writeConsts(&b, "Defined template names.", nameIdents)
+	if cfg.render {
+		name := ""
+		if cfg.name != "" {
+			name = fmt.Sprintf(", TemplateName: %q", cfg.name)
+			for _, c := range nameIdents {
+				if c.value == cfg.name {
+					name = ", TemplateName: " + c.ident
+				}
+			}
+		}
+		for _, c := range keyIdents {
+			ident := strings.TrimPrefix(c.ident, "Key")
+			data := ident + "Data"
+			if unicode.IsDigit([]rune(ident)[0]) {
+				data = "Page" + data
+			}
+			fmt.Fprintf(&b, "// Render%s replies with HTTP Status 200 OK and the template %s.\n", ident, c.ident)
+			fmt.Fprintf(&b, "func Render%s(w http.ResponseWriter, data %s) {\n", ident, data)
+			fmt.Fprintf(&b, "%s.OK(w, reply.Options{TemplateKey: %s%s, Data: data})\n}\n\n", cfg.engine, c.ident, name)
+		}
+	}
+	return format.Source(b.Bytes())
+}
+
+// constant is a generated constant.
+type constant struct {
+	ident string
+	value string
+}
+
+// writeConsts writes a const block of consts, if any, to b.
+func writeConsts(b *bytes.Buffer, doc string, consts []constant) {
+	if len(consts) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "// %s\nconst (\n", doc)
+	for _, c := range consts {
+		fmt.Fprintf(b, "%s = %q\n", c.ident, c.value)
+	}
+	b.WriteString(")\n\n")
+}
+
+// identifiers returns constants of values, named by prefix and the words of
+// each value, such as KeyAdminIndex for "admin/index.html" if trimExt is true.
+// Values whose names collide return an error.
+func identifiers(prefix string, values []string, trimExt bool) ([]constant, error) {
+	consts := make([]constant, len(values))
+	seen := map[string]string{}
+	for i, v := range values {
+		if trimExt {
+			v = strings.TrimSuffix(v, path.Ext(v))
+		}
+		words := strings.FieldsFunc(v, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		ident := prefix
+		for _, w := range words {
+			r := []rune(w)
+			ident += string(unicode.ToUpper(r[0])) + string(r[1:])
+		}
+		if other, ok := seen[ident]; ok {
+			return nil, fmt.Errorf("%q and %q both generate %s", other, values[i], ident)
+		}
+		seen[ident] = values[i]
+		consts[i] = constant{ident: ident, value: values[i]}
+	}
+	return consts, nil
+}
+
+// sortedNames returns the sorted keys of names.
+func sortedNames(names map[string]bool) []string {
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// funcStubs returns a FuncMap of stubs for the functions called by the
+// templates in fsys, which are not known to replygen, so that the templates
+// can be parsed. Files that fail to parse are skipped.
+func funcStubs(fsys fs.FS) (template.FuncMap, error) {
+	funcs := template.FuncMap{}
+	err := fs.WalkDir(fsys, ".", func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		t := parse.New(file)
+		t.Mode = parse.SkipFuncCheck
+		trees := map[string]*parse.Tree{}
+		if _, err := t.Parse(string(b), "", "", trees); err != nil {
+			return nil
+		}
+		for _, tree := range trees {
+			collectFuncs(tree.Root, funcs)
+		}
+		return nil
+	})
+	return funcs, err
+}
+
+// collectFuncs adds a stub to funcs for each function called within node.
+func collectFuncs(node parse.Node, funcs template.FuncMap) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectFuncs(child, funcs)
+		}
+	case *parse.ActionNode:
+		collectFuncs(n.Pipe, funcs)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			collectFuncs(cmd, funcs)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			collectFuncs(arg, funcs)
+		}
+	case *parse.ChainNode:
+		collectFuncs(n.Node, funcs)
+	case *parse.IdentifierNode:
+		funcs[n.Ident] = func(...any) any { return nil }
+	case *parse.IfNode:
+		collectFuncs(&n.BranchNode, funcs)
+	case *parse.RangeNode:
+		collectFuncs(&n.BranchNode, funcs)
+	case *parse.WithNode:
+		collectFuncs(&n.BranchNode, funcs)
+	case *parse.BranchNode:
+		collectFuncs(n.Pipe, funcs)
+		collectFuncs(n.List, funcs)
+		collectFuncs(n.ElseList, funcs)
+	case *parse.TemplateNode:
+		collectFuncs(n.Pipe, funcs)
+	}
+}
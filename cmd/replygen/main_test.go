@@ -0,0 +1,145 @@
+package main
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/novrin/reply"
+)
+
+const errorString = "\nGot:\t%#v\nWant:\t%#v\n"
+
+func TestGenerate(t *testing.T) {
+	fsys := fstest.MapFS{
+		"base.html":               {Data: []byte(`{{define "base"}}{{template "nav"}}{{template "content" .}}{{end}}`)},
+		"partials/nav.html":       {Data: []byte(`{{define "nav"}}<nav>{{upper "menu"}}</nav>{{end}}`)},
+		"pages/user_show.html":    {Data: []byte(`{{define "content"}}{{.Name | truncate 10}}{{end}}`)},
+		"pages/home.html":         {Data: []byte(`{{define "content"}}{{if eq .Count 1}}one{{end}}{{end}}`)},
+		"pages/admin/index.html":  {Data: []byte(`{{define "content"}}{{range .}}{{format .}}{{end}}{{end}}`)},
+		"pages/user-show.html":    {Data: []byte(`{{define "content"}}{{end}}`)},
+		"collide/user-show.html":  {Data: []byte(`{{define "content"}}{{end}}`)},
+		"collide/user_show.html":  {Data: []byte(`{{define "content"}}{{end}}`)},
+		"pages/README.md":         {Data: []byte(`{{ not a template`)},
+		"broken/pages/index.html": {Data: []byte(`{{define "content"}`)},
+		"errors/404.html":         {Data: []byte(`{{.Message}}`)},
+		"errors/4xx.html":         {Data: []byte(`{{.Code}}`)},
+	}
+	cases := map[string]struct {
+		cfg     config
+		want    string
+		wantErr string
+	}{
+		"constants": {
+			cfg: config{
+				TemplateConfig: reply.TemplateConfig{Pages: "pages/*_*.html", Shared: []string{"base.html", "partials/*.html"}},
+				pkg:            "views",
+			},
+			want: `// Code generated by replygen; DO NOT EDIT.
+
+package views
+
+// Template keys.
+const (
+	KeyUserShow = "user_show.html"
+)
+
+// Defined template names.
+const (
+	NameBase    = "base"
+	NameContent = "content"
+	NameNav     = "nav"
+)
+`,
+		},
+		"key collision": {
+			cfg:     config{TemplateConfig: reply.TemplateConfig{Pages: "collide/*.html"}, pkg: "views"},
+			wantErr: `"user-show.html" and "user_show.html" both generate KeyUserShow`,
+		},
+		"parse error": {
+			cfg:     config{TemplateConfig: reply.TemplateConfig{Pages: "broken/pages/*.html"}, pkg: "views"},
+			wantErr: `template: index.html:1: unexpected "}" in define clause`,
+		},
+		"render helpers": {
+			cfg: config{
+				TemplateConfig: reply.TemplateConfig{Pages: "pages/admin/*.html", Recursive: true, Shared: []string{"base.html", "partials/*.html"}},
+				pkg:            "views",
+				render:         true,
+				engine:         "engine",
+				name:           "base",
+			},
+			want: `// Code generated by replygen; DO NOT EDIT.
+
+package views
+
+import (
+	"net/http"
+
+	"github.com/novrin/reply"
+)
+
+// Template keys.
+const (
+	KeyIndex = "index.html"
+)
+
+// Defined template names.
+const (
+	NameBase    = "base"
+	NameContent = "content"
+	NameNav     = "nav"
+)
+
+// RenderIndex replies with HTTP Status 200 OK and the template KeyIndex.
+func RenderIndex(w http.ResponseWriter, data IndexData) {
+	engine.OK(w, reply.Options{TemplateKey: KeyIndex, TemplateName: NameBase, Data: data})
+}
+`,
+		},
+		"render helpers; status pages": {
+			cfg: config{TemplateConfig: reply.TemplateConfig{Pages: "errors/*.html"}, pkg: "views", render: true, engine: "engine"},
+			want: `// Code generated by replygen; DO NOT EDIT.
+
+package views
+
+import (
+	"net/http"
+
+	"github.com/novrin/reply"
+)
+
+// Template keys.
+const (
+	Key404 = "404.html"
+	Key4xx = "4xx.html"
+)
+
+// Render404 replies with HTTP Status 200 OK and the template Key404.
+func Render404(w http.ResponseWriter, data Page404Data) {
+	engine.OK(w, reply.Options{TemplateKey: Key404, Data: data})
+}
+
+// Render4xx replies with HTTP Status 200 OK and the template Key4xx.
+func Render4xx(w http.ResponseWriter, data Page4xxData) {
+	engine.OK(w, reply.Options{TemplateKey: Key4xx, Data: data})
+}
+`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := generate(fsys, c.cfg)
+			if c.wantErr != "" {
+				if err == nil || err.Error() != c.wantErr {
+					t.Errorf(errorString, err, c.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != c.want {
+				t.Errorf(errorString, string(got), c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package reply
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Encoder returns a WriteCloser that compresses what is written to it into w.
+type Encoder func(w io.Writer) (io.WriteCloser, error)
+
+// Compression configures the compression of reply bodies negotiated through
+// the Accept-Encoding header of Options.Request.
+type Compression struct {
+	// MinSize defines the size in bytes under which bodies are not compressed.
+	MinSize int
+
+	// Types defines the media types of compressible bodies. Entries may be
+	// patterns such as "text/*". If empty, DefaultCompressibleTypes is used.
+	Types []string
+
+	// Encoders defines the available encoders by content-coding, such as
+	// "gzip" or "zstd". If nil, DefaultEncoders is used.
+	Encoders map[string]Encoder
+}
+
+// DefaultCompressibleTypes are the media types compressed by default.
+var DefaultCompressibleTypes = []string{
+	"text/*",
+	"application/json",
+	"application/*+json",
+	"application/javascript",
+	"application/xml",
+	"application/*+xml",
+	"image/svg+xml",
+}
+
+// DefaultEncoders are the encoders used by default.
+var DefaultEncoders = map[string]Encoder{
+	"gzip": func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriter(w), nil
+	},
+	"deflate": func(w io.Writer) (io.WriteCloser, error) {
+		return zlib.NewWriter(w), nil
+	},
+}
+
+// encoders returns the encoders available to c.
+func (c *Compression) encoders() map[string]Encoder {
+	if c.Encoders == nil {
+		return DefaultEncoders
+	}
+	return c.Encoders
+}
+
+// compressible reports whether a reply with the given status code and
+// content type may be compressed.
+func (c *Compression) compressible(code int, contentType string) bool {
+	if code < http.StatusOK || code == http.StatusNoContent || code == http.StatusNotModified {
+		return false
+	}
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	types := c.Types
+	if len(types) == 0 {
+		types = DefaultCompressibleTypes
+	}
+	for _, pattern := range types {
+		if ok, _ := path.Match(pattern, mediaType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiate returns the content-coding preferred by r amongst c's encoders,
+// or an empty string if r accepts none. Ties between equal quality values
+// are broken by the order of the Accept-Encoding header.
+func (c *Compression) negotiate(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	encoders := c.encoders()
+	best, bestQ := "", 0.0
+	wildcard := -1.0
+	rejected := map[string]bool{}
+	for _, accept := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(accept, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if coding == "*" {
+			wildcard = q
+			continue
+		}
+		if q <= 0 {
+			rejected[coding] = true
+			continue
+		}
+		if _, ok := encoders[coding]; ok && q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	if best == "" && wildcard > 0 {
+		// Any encoder not otherwise listed is acceptable; pick one stably.
+		for _, coding := range sortedKeys(encoders) {
+			if !rejected[coding] {
+				return coding
+			}
+		}
+	}
+	return best
+}
+
+// compress returns the coding and body of buf compressed for r, or an empty
+// coding and buf if it is not to be compressed. A compressed body is a pooled
+// buffer the caller should return with putBuffer.
+func (c *Compression) compress(r *http.Request, buf *bytes.Buffer) (string, *bytes.Buffer) {
+	if buf.Len() < c.MinSize {
+		return "", buf
+	}
+	coding := c.negotiate(r)
+	if coding == "" {
+		return "", buf
+	}
+	compressed := getBuffer()
+	enc, err := c.encoders()[coding](compressed)
+	if err == nil {
+		_, err = enc.Write(buf.Bytes())
+	}
+	if err == nil {
+		err = enc.Close()
+	}
+	if err != nil {
+		putBuffer(compressed)
+		return "", buf
+	}
+	return coding, compressed
+}
+
+// addVary adds value to the Vary header of h unless already present.
+func addVary(h http.Header, value string) {
+	for _, v := range h.Values("Vary") {
+		for _, field := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), value) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", value)
+}
+
+// sortedKeys returns the keys of m in increasing order.
+func sortedKeys(m map[string]Encoder) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,155 @@
+package reply
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	zstd := func(w io.Writer) (io.WriteCloser, error) { return nil, nil }
+	cases := map[string]struct {
+		encoders map[string]Encoder
+		accept   string
+		want     string
+	}{
+		"none":                 {accept: "", want: ""},
+		"identity":             {accept: "identity", want: ""},
+		"gzip":                 {accept: "gzip", want: "gzip"},
+		"header order":         {accept: "deflate, gzip", want: "deflate"},
+		"quality":              {accept: "deflate;q=0.5, gzip;q=0.8", want: "gzip"},
+		"rejected":             {accept: "gzip;q=0, deflate", want: "deflate"},
+		"unknown":              {accept: "br", want: ""},
+		"wildcard":             {accept: "br, *", want: "deflate"},
+		"wildcard; rejected":   {accept: "deflate;q=0, *", want: "gzip"},
+		"wildcard; not wanted": {accept: "*;q=0", want: ""},
+		"registered encoder": {
+			encoders: map[string]Encoder{"zstd": zstd, "gzip": DefaultEncoders["gzip"]},
+			accept:   "gzip;q=0.9, zstd",
+			want:     "zstd",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Encoding", c.accept)
+			cp := &Compression{Encoders: c.encoders}
+			if got := cp.negotiate(r); got != c.want {
+				t.Errorf(errorString, got, c.want)
+			}
+		})
+	}
+}
+
+func TestCompression(t *testing.T) {
+	long := strings.Repeat("Elementary. ", 100)
+	page := template.Must(template.New("page").Parse(`{{.}}`))
+	cases := map[string]struct {
+		writer       Writer
+		code         int
+		accept       string
+		vary         string
+		data         string
+		wantEncoding string
+		wantVary     string
+	}{
+		"gzip json": {
+			writer:       JSONWriter{Body: Body{Compression: &Compression{}}},
+			code:         http.StatusOK,
+			accept:       "gzip",
+			data:         long,
+			wantEncoding: "gzip",
+			wantVary:     "Accept-Encoding",
+		},
+		"deflate html": {
+			writer: &TemplateWriter{
+				Templates: map[string]*template.Template{"page": page},
+				Body:      Body{Compression: &Compression{}},
+			},
+			code:         http.StatusOK,
+			accept:       "deflate",
+			data:         long,
+			wantEncoding: "deflate",
+			wantVary:     "Accept-Encoding",
+		},
+		"no accept-encoding": {
+			writer:   JSONWriter{Body: Body{Compression: &Compression{}}},
+			code:     http.StatusOK,
+			data:     long,
+			wantVary: "Accept-Encoding",
+		},
+		"under min size": {
+			writer:   JSONWriter{Body: Body{Compression: &Compression{MinSize: 1024}}},
+			code:     http.StatusOK,
+			accept:   "gzip",
+			data:     "short",
+			wantVary: "Accept-Encoding",
+		},
+		"type not allowed": {
+			writer:   JSONWriter{Body: Body{Compression: &Compression{Types: []string{"text/html"}}}},
+			code:     http.StatusOK,
+			accept:   "gzip",
+			data:     long,
+			wantVary: "",
+		},
+		"existing vary": {
+			writer:       JSONWriter{Body: Body{Compression: &Compression{}}},
+			code:         http.StatusOK,
+			accept:       "gzip",
+			vary:         "Cookie, accept-encoding",
+			data:         long,
+			wantEncoding: "gzip",
+			wantVary:     "Cookie, accept-encoding",
+		},
+		"no content": {
+			writer: JSONWriter{Body: Body{Compression: &Compression{}}},
+			code:   http.StatusNoContent,
+			accept: "gzip",
+			data:   long,
+		},
+		"compression disabled": {
+			writer: JSONWriter{},
+			code:   http.StatusOK,
+			accept: "gzip",
+			data:   long,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Encoding", c.accept)
+			w := httptest.NewRecorder()
+			if c.vary != "" {
+				w.Header().Set("Vary", c.vary)
+			}
+			if err := c.writer.Reply(w, c.code, Options{TemplateKey: "page", Data: c.data, Request: r}); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Header().Get("Content-Encoding"); got != c.wantEncoding {
+				t.Errorf(errorString, got, c.wantEncoding)
+			}
+			if got := strings.Join(w.Header().Values("Vary"), ", "); got != c.wantVary {
+				t.Errorf(errorString, got, c.wantVary)
+			}
+			var body io.Reader = w.Body
+			switch c.wantEncoding {
+			case "gzip":
+				body, _ = gzip.NewReader(w.Body)
+			case "deflate":
+				body, _ = zlib.NewReader(w.Body)
+			}
+			b, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); !strings.Contains(got, c.data) && c.code != http.StatusNoContent {
+				t.Errorf("body not decoded; got %d bytes", len(got))
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package reply
 
 import (
+	"errors"
 	"net/http"
 )
 
@@ -18,8 +19,70 @@ type Engine struct {
 	// be the plain text representation of the error code.
 	Debug bool
 
+	// Cache defines optional default Cache-Control policies for replies and
+	// errors. Options.Cache overrides the policy of a reply.
+	Cache CachePolicy
+
+	// Localizer defines an optional Localizer translating the error messages
+	// of Engines returned by WithRequest to the locale of their request.
+	Localizer *Localizer
+
 	// Writer is an interface used to construct replies to HTTP server requests.
 	Writer
+
+	// request is the request being replied to, set by WithRequest.
+	request *http.Request
+}
+
+// WithRequest returns a copy of e replying to r. Its error messages are
+// translated to the locale of r by e's Localizer, and r is the Options.Request
+// of its replies unless one is given.
+func (e Engine) WithRequest(r *http.Request) Engine {
+	e.request = r
+	return e
+}
+
+// translate returns msg translated to the locale of e's request, if e has a
+// request and a Localizer.
+func (e Engine) translate(msg string) string {
+	if e.Localizer == nil || e.request == nil {
+		return msg
+	}
+	return e.Localizer.T(e.Localizer.Locale(e.request), msg)
+}
+
+// Error wraps the Writer's Error, setting the Cache-Control header of the
+// error reply if e.Cache has an Errors policy. If e has a request, Writers
+// that render error details, such as a TemplateWriter, reply with its locale.
+func (e Engine) Error(w http.ResponseWriter, error string, code int) {
+	if _, ok := e.Writer.(detailsWriter); ok && e.request != nil {
+		e.ErrorDetails(w, error, code, nil)
+		return
+	}
+	if e.Cache.Errors != nil {
+		w.Header().Set("Cache-Control", e.Cache.Errors.String())
+	}
+	e.Writer.Error(w, e.translate(error), code)
+}
+
+// detailsWriter is implemented by Writers that render the details of errors
+// and the request replied to, such as a TemplateWriter.
+type detailsWriter interface {
+	ErrorDetails(w http.ResponseWriter, r *http.Request, error string, code int, details any)
+}
+
+// ErrorDetails replies like Error, with details of the error for Writers that
+// render them, such as a TemplateWriter. Other Writers reply with Error.
+func (e Engine) ErrorDetails(w http.ResponseWriter, error string, code int, details any) {
+	dw, ok := e.Writer.(detailsWriter)
+	if !ok {
+		e.Error(w, error, code)
+		return
+	}
+	if e.Cache.Errors != nil {
+		w.Header().Set("Cache-Control", e.Cache.Errors.String())
+	}
+	dw.ErrorDetails(w, e.request, e.translate(error), code, details)
 }
 
 // BadRequest replies with HTTP Status 400 Bad Request.
@@ -214,10 +277,32 @@ func (e Engine) NetworkAuthenticationRequired(w http.ResponseWriter) {
 
 // ReplyOrError wraps Reply with error debugging. If an error is encountered in
 // Reply, the Writer's Error function is triggered. Error essages are replaced
-// with 'Internal Server Error' if e.Debug is false.
+// with 'Internal Server Error' if e.Debug is false. A *FieldError replies with
+// 'Bad Request' instead, and a *StreamError, whose reply has already been
+// sent in part, is not replied to. The Cache-Control header of the reply is
+// set from opts.Cache or e.Cache, and restored if the reply fails.
 func (e Engine) ReplyOrError(w http.ResponseWriter, code int, opts Options) {
+	if opts.Request == nil {
+		opts.Request = e.request
+	}
+	prev := w.Header().Values("Cache-Control")
+	if cc := opts.Cache; cc != nil {
+		w.Header().Set("Cache-Control", cc.String())
+	} else if cc := e.Cache.Replies; cc != nil {
+		w.Header().Set("Cache-Control", cc.String())
+	}
 	if err := e.Reply(w, code, opts); err != nil {
+		if isStreamError(err) {
+			return // the reply has been sent in part
+		}
+		if w.Header().Del("Cache-Control"); prev != nil {
+			w.Header()["Cache-Control"] = prev
+		}
 		code = http.StatusInternalServerError
+		var fe *FieldError
+		if errors.As(err, &fe) {
+			code = http.StatusBadRequest
+		}
 		if !e.Debug {
 			e.Error(w, http.StatusText(code), code)
 		} else {
@@ -458,6 +458,20 @@ func TestReplyOrError(t *testing.T) {
 			wantCode: http.StatusInternalServerError,
 			wantBody: `{"error":"json: unsupported type: chan int"}`,
 		},
+		"error unknown field, debug false - jw": {
+			reply:    Engine{Writer: JSONWriter{StrictFields: true}},
+			code:     http.StatusOK,
+			opts:     Options{Data: map[string]string{"foo": "bar"}, Fields: "baz"},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"error":"Bad Request"}`,
+		},
+		"error unknown field, debug true - jw": {
+			reply:    Engine{Writer: JSONWriter{StrictFields: true}, Debug: true},
+			code:     http.StatusOK,
+			opts:     Options{Data: map[string]string{"foo": "bar"}, Fields: "baz"},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"error":"unknown field 'baz'"}`,
+		},
 		"ok - jw": {
 			reply:    Engine{Writer: JSONWriter{}},
 			code:     http.StatusOK,
@@ -558,3 +572,29 @@ func TestGenericReplies(t *testing.T) {
 		})
 	}
 }
+
+// discardWriter is an http.ResponseWriter that discards its body, so that
+// benchmarks measure replies rather than recording.
+type discardWriter struct {
+	header http.Header
+}
+
+func (dw discardWriter) Header() http.Header         { return dw.header }
+func (dw discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (dw discardWriter) WriteHeader(int)             {}
+
+func BenchmarkErrors(b *testing.B) {
+	cases := map[string]Engine{
+		"tw": {Writer: NewTemplateWriter(map[string]*template.Template{})},
+		"jw": {Writer: JSONWriter{}},
+	}
+	for name, e := range cases {
+		b.Run(name, func(b *testing.B) {
+			w := discardWriter{header: http.Header{}}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				e.NotFound(w)
+			}
+		})
+	}
+}
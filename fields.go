@@ -0,0 +1,113 @@
+package reply
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// FieldError is returned by a JSONWriter with StrictFields when Options.Fields
+// selects a field that does not exist in the encoded data.
+type FieldError struct {
+	Field string
+}
+
+func (fe *FieldError) Error() string {
+	return fmt.Sprintf("unknown field '%s'", fe.Field)
+}
+
+// fieldTree is a parsed field-selection expression. A nil subtree selects a
+// field entirely.
+type fieldTree map[string]fieldTree
+
+// parseFields parses a comma separated list of dot separated paths, such as
+// "id,name,owner.email", into a fieldTree.
+func parseFields(fields string) fieldTree {
+	tree := fieldTree{}
+	for _, path := range strings.Split(fields, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		node := tree
+		parts := strings.Split(path, ".")
+		for i, part := range parts {
+			child, ok := node[part]
+			if ok && child == nil {
+				break // the field is already selected entirely
+			}
+			if i == len(parts)-1 {
+				node[part] = nil
+				break
+			}
+			if !ok {
+				child = fieldTree{}
+				node[part] = child
+			}
+			node = child
+		}
+	}
+	return tree
+}
+
+// selectFields returns the JSON encoding of data restricted to the fields
+// selected by the expression fields. Objects are walked by key and arrays are
+// walked element by element. If strict is true, selecting a key absent from an
+// object returns a *FieldError.
+func selectFields(data any, fields string, strict bool) (json.RawMessage, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := json.NewEncoder(buf).Encode(data); err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(buf)
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	v, err := parseFields(fields).apply(v, "", strict)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// apply restricts v to the fields in ft. prefix is the path of v, used to
+// report unknown fields.
+func (ft fieldTree) apply(v any, prefix string, strict bool) (any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		selected := make(map[string]any, len(ft))
+		for key, child := range ft {
+			val, ok := v[key]
+			if !ok {
+				if strict {
+					return nil, &FieldError{Field: prefix + key}
+				}
+				continue
+			}
+			if child == nil {
+				selected[key] = val
+				continue
+			}
+			val, err := child.apply(val, prefix+key+".", strict)
+			if err != nil {
+				return nil, err
+			}
+			selected[key] = val
+		}
+		return selected, nil
+	case []any:
+		for i, elem := range v {
+			elem, err := ft.apply(elem, prefix, strict)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = elem
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
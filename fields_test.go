@@ -0,0 +1,93 @@
+package reply
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSelectFields(t *testing.T) {
+	type owner struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	type item struct {
+		ID    int    `json:"id"`
+		Name  string `json:"name"`
+		Owner *owner `json:"owner"`
+	}
+	items := []item{
+		{ID: 1, Name: "pipe", Owner: &owner{Name: "Sherlock", Email: "sh@221b.uk"}},
+		{ID: 2, Name: "violin", Owner: nil},
+	}
+	cases := map[string]struct {
+		data      any
+		fields    string
+		strict    bool
+		wantErr   string
+		wantField string
+		want      string
+	}{
+		"top level": {
+			data:   items[0],
+			fields: "id,name",
+			want:   `{"id":1,"name":"pipe"}`,
+		},
+		"nested path": {
+			data:   items[0],
+			fields: "id, owner.email",
+			want:   `{"id":1,"owner":{"email":"sh@221b.uk"}}`,
+		},
+		"whole object wins over nested path": {
+			data:   items[0],
+			fields: "owner.email,owner",
+			want:   `{"owner":{"email":"sh@221b.uk","name":"Sherlock"}}`,
+		},
+		"array; null nested value": {
+			data:   items,
+			fields: "id,owner.name",
+			want:   `[{"id":1,"owner":{"name":"Sherlock"}},{"id":2,"owner":null}]`,
+		},
+		"unknown field ignored": {
+			data:   items[0],
+			fields: "id,colour",
+			want:   `{"id":1}`,
+		},
+		"unknown field strict": {
+			data:      items[0],
+			fields:    "id,colour",
+			strict:    true,
+			wantField: "colour",
+		},
+		"unknown nested field strict": {
+			data:      items,
+			fields:    "owner.phone",
+			strict:    true,
+			wantField: "owner.phone",
+		},
+		"error - fail encode": {
+			data:    map[string]any{"foo": make(chan int)},
+			fields:  "foo",
+			wantErr: "json: unsupported type: chan int",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := selectFields(c.data, c.fields, c.strict)
+			var fe *FieldError
+			switch {
+			case c.wantField != "":
+				if !errors.As(err, &fe) || fe.Field != c.wantField {
+					t.Errorf(errorString, err, c.wantField)
+				}
+			case c.wantErr != "":
+				if err == nil || err.Error() != c.wantErr {
+					t.Errorf(errorString, err, c.wantErr)
+				}
+			case err != nil:
+				t.Errorf(errorString, err, nil)
+			case string(got) != c.want:
+				t.Errorf(errorString, string(got), c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package reply
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// File replies with content for display in the browser, with name as its
+// suggested filename. It wraps http.ServeContent, which answers Range,
+// If-Range and conditional requests using modtime and sets the Content-Type
+// from name's extension or content. Errors, such as an unsatisfiable range,
+// are replied to through e's Writer.
+func (e Engine) File(w http.ResponseWriter, r *http.Request, name string, content io.ReadSeeker, modtime time.Time) {
+	e.serveContent(w, r, "inline", name, content, modtime)
+}
+
+// Attachment replies with content for download like File, as an attachment
+// with name as its suggested filename.
+func (e Engine) Attachment(w http.ResponseWriter, r *http.Request, name string, content io.ReadSeeker, modtime time.Time) {
+	e.serveContent(w, r, "attachment", name, content, modtime)
+}
+
+// FileFS replies with the file name opened from fsys like File. If the file
+// does not exist or is a directory, it replies with HTTP Status 404 Not Found,
+// and if permission is denied, with HTTP Status 403 Forbidden.
+func (e Engine) FileFS(w http.ResponseWriter, r *http.Request, fsys fs.FS, name string) {
+	e.serveFS(w, r, "inline", fsys, name)
+}
+
+// AttachmentFS replies with the file name opened from fsys like FileFS, as an
+// attachment.
+func (e Engine) AttachmentFS(w http.ResponseWriter, r *http.Request, fsys fs.FS, name string) {
+	e.serveFS(w, r, "attachment", fsys, name)
+}
+
+// serveFS replies with the file name from fsys with the given disposition.
+func (e Engine) serveFS(w http.ResponseWriter, r *http.Request, disposition string, fsys fs.FS, name string) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		e.fileError(w, err)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		e.fileError(w, err)
+		return
+	}
+	if info.IsDir() {
+		e.NotFound(w)
+		return
+	}
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		b, err := io.ReadAll(f)
+		if err != nil {
+			e.fileError(w, err)
+			return
+		}
+		content = bytes.NewReader(b)
+	}
+	e.serveContent(w, r, disposition, info.Name(), content, info.ModTime())
+}
+
+// fileError replies to err, an error opening or reading a file. Invalid
+// names, such as those escaping the file system, are not found.
+func (e Engine) fileError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, fs.ErrInvalid):
+		e.NotFound(w)
+	case errors.Is(err, fs.ErrPermission):
+		e.Forbidden(w)
+	case e.Debug:
+		e.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		e.InternalServerError(w)
+	}
+}
+
+// serveContent replies with content using http.ServeContent, replying to the
+// errors it writes through e's Writer instead.
+func (e Engine) serveContent(w http.ResponseWriter, r *http.Request, disposition, name string, content io.ReadSeeker, modtime time.Time) {
+	w.Header().Set("Content-Disposition", contentDisposition(disposition, name))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	ew := &errorInterceptor{ResponseWriter: w}
+	http.ServeContent(ew, r, name, modtime, content)
+	if ew.code != 0 {
+		w.Header().Del("Content-Disposition")
+		w.Header().Del("Last-Modified")
+		e.Error(w, http.StatusText(ew.code), ew.code)
+	}
+}
+
+// errorInterceptor is an http.ResponseWriter that withholds error replies,
+// recording their status code instead.
+type errorInterceptor struct {
+	http.ResponseWriter
+	code int
+}
+
+func (ei *errorInterceptor) WriteHeader(code int) {
+	if code >= http.StatusBadRequest {
+		ei.code = code
+		return
+	}
+	ei.ResponseWriter.WriteHeader(code)
+}
+
+func (ei *errorInterceptor) Write(b []byte) (int, error) {
+	if ei.code != 0 {
+		return len(b), nil
+	}
+	return ei.ResponseWriter.Write(b)
+}
+
+// contentDisposition returns a Content-Disposition header value for the given
+// disposition and filename. Filenames that are not plain ASCII are given an
+// ASCII fallback and an RFC 5987 encoded filename* parameter (RFC 6266).
+func contentDisposition(disposition, name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	fallback := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' || r == '%' {
+			return '_'
+		}
+		return r
+	}, name)
+	v := disposition + `; filename="` + fallback + `"`
+	if fallback != name {
+		v += "; filename*=UTF-8''" + encodeExtValue(name)
+	}
+	return v
+}
+
+// encodeExtValue percent-encodes s as the value-chars of an RFC 5987
+// ext-value.
+func encodeExtValue(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+			strings.IndexByte("!#$&+-.^_`|~", c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&0x0f])
+	}
+	return b.String()
+}
@@ -0,0 +1,212 @@
+package reply
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestFile(t *testing.T) {
+	modtime := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	ejw := Engine{Writer: JSONWriter{}}
+	cases := map[string]struct {
+		header          map[string]string
+		attachment      bool
+		name            string
+		wantCode        int
+		wantType        string
+		wantDisposition string
+		wantBody        string
+	}{
+		"inline": {
+			name:            "report.txt",
+			wantCode:        http.StatusOK,
+			wantType:        "text/plain; charset=utf-8",
+			wantDisposition: `inline; filename="report.txt"`,
+			wantBody:        "0123456789",
+		},
+		"attachment": {
+			attachment:      true,
+			name:            "report.txt",
+			wantCode:        http.StatusOK,
+			wantType:        "text/plain; charset=utf-8",
+			wantDisposition: `attachment; filename="report.txt"`,
+			wantBody:        "0123456789",
+		},
+		"attachment; non-ascii name": {
+			attachment:      true,
+			name:            "exports/résumé 100%.txt",
+			wantCode:        http.StatusOK,
+			wantType:        "text/plain; charset=utf-8",
+			wantDisposition: `attachment; filename="r_sum_ 100_.txt"; filename*=UTF-8''r%C3%A9sum%C3%A9%20100%25.txt`,
+			wantBody:        "0123456789",
+		},
+		"range": {
+			header:          map[string]string{"Range": "bytes=2-5"},
+			name:            "report.txt",
+			wantCode:        http.StatusPartialContent,
+			wantType:        "text/plain; charset=utf-8",
+			wantDisposition: `inline; filename="report.txt"`,
+			wantBody:        "2345",
+		},
+		"range; if-range outdated": {
+			header:          map[string]string{"Range": "bytes=2-5", "If-Range": modtime.Add(-time.Hour).Format(http.TimeFormat)},
+			name:            "report.txt",
+			wantCode:        http.StatusOK,
+			wantType:        "text/plain; charset=utf-8",
+			wantDisposition: `inline; filename="report.txt"`,
+			wantBody:        "0123456789",
+		},
+		"not modified": {
+			header:          map[string]string{"If-Modified-Since": modtime.Format(http.TimeFormat)},
+			name:            "report.txt",
+			wantCode:        http.StatusNotModified,
+			wantDisposition: `inline; filename="report.txt"`,
+		},
+		"range not satisfiable": {
+			header:   map[string]string{"Range": "bytes=20-30"},
+			name:     "report.txt",
+			wantCode: http.StatusRequestedRangeNotSatisfiable,
+			wantType: "application/json",
+			wantBody: `{"error":"Requested Range Not Satisfiable"}`,
+		},
+		"precondition failed": {
+			header:   map[string]string{"If-Unmodified-Since": modtime.Add(-time.Hour).Format(http.TimeFormat)},
+			name:     "report.txt",
+			wantCode: http.StatusPreconditionFailed,
+			wantType: "application/json",
+			wantBody: `{"error":"Precondition Failed"}`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range c.header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			content := strings.NewReader("0123456789")
+			if c.attachment {
+				ejw.Attachment(w, r, c.name, content, modtime)
+			} else {
+				ejw.File(w, r, c.name, content, modtime)
+			}
+			if got := w.Code; got != c.wantCode {
+				t.Errorf(errorString, got, c.wantCode)
+			}
+			if got := w.Header().Get("Content-Type"); got != c.wantType {
+				t.Errorf(errorString, got, c.wantType)
+			}
+			if got := w.Header().Get("Content-Disposition"); got != c.wantDisposition {
+				t.Errorf(errorString, got, c.wantDisposition)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != c.wantBody {
+				t.Errorf(errorString, got, c.wantBody)
+			}
+		})
+	}
+}
+
+// permissionFS is an fs.FS denying permission to open any file.
+type permissionFS struct{}
+
+func (permissionFS) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+}
+
+func TestFileFS(t *testing.T) {
+	modtime := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	mapfs := fstest.MapFS{
+		"docs/guide.html": {Data: []byte("<h1>Guide</h1>"), ModTime: modtime},
+	}
+	ejw := Engine{Writer: JSONWriter{}}
+	cases := map[string]struct {
+		fsys             fs.FS
+		name             string
+		attachment       bool
+		wantCode         int
+		wantType         string
+		wantDisposition  string
+		wantLastModified string
+		wantBody         string
+	}{
+		"inline": {
+			fsys:             mapfs,
+			name:             "docs/guide.html",
+			wantCode:         http.StatusOK,
+			wantType:         "text/html; charset=utf-8",
+			wantDisposition:  `inline; filename="guide.html"`,
+			wantLastModified: modtime.Format(http.TimeFormat),
+			wantBody:         "<h1>Guide</h1>",
+		},
+		"attachment": {
+			fsys:             mapfs,
+			name:             "docs/guide.html",
+			attachment:       true,
+			wantCode:         http.StatusOK,
+			wantType:         "text/html; charset=utf-8",
+			wantDisposition:  `attachment; filename="guide.html"`,
+			wantLastModified: modtime.Format(http.TimeFormat),
+			wantBody:         "<h1>Guide</h1>",
+		},
+		"not found": {
+			fsys:     mapfs,
+			name:     "docs/missing.html",
+			wantCode: http.StatusNotFound,
+			wantType: "application/json",
+			wantBody: `{"error":"Not Found"}`,
+		},
+		"invalid name": {
+			fsys:     os.DirFS("."),
+			name:     "../etc/passwd",
+			wantCode: http.StatusNotFound,
+			wantType: "application/json",
+			wantBody: `{"error":"Not Found"}`,
+		},
+		"directory": {
+			fsys:     mapfs,
+			name:     "docs",
+			wantCode: http.StatusNotFound,
+			wantType: "application/json",
+			wantBody: `{"error":"Not Found"}`,
+		},
+		"permission denied": {
+			fsys:     permissionFS{},
+			name:     "docs/guide.html",
+			wantCode: http.StatusForbidden,
+			wantType: "application/json",
+			wantBody: `{"error":"Forbidden"}`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			w := httptest.NewRecorder()
+			if c.attachment {
+				ejw.AttachmentFS(w, r, c.fsys, c.name)
+			} else {
+				ejw.FileFS(w, r, c.fsys, c.name)
+			}
+			if got := w.Code; got != c.wantCode {
+				t.Errorf(errorString, got, c.wantCode)
+			}
+			if got := w.Header().Get("Content-Type"); got != c.wantType {
+				t.Errorf(errorString, got, c.wantType)
+			}
+			if got := w.Header().Get("Content-Disposition"); got != c.wantDisposition {
+				t.Errorf(errorString, got, c.wantDisposition)
+			}
+			if got := w.Header().Get("Last-Modified"); got != c.wantLastModified {
+				t.Errorf(errorString, got, c.wantLastModified)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != c.wantBody {
+				t.Errorf(errorString, got, c.wantBody)
+			}
+		})
+	}
+}
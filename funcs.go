@@ -0,0 +1,206 @@
+package reply
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"math"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// FuncMap returns a FuncMap of common template helpers merged with funcs, whose
+// functions win on conflicts. The helpers are:
+//
+//	date LAYOUT TIME          formats a time.Time or *time.Time, "" if zero
+//	pluralize N ONE MANY      ONE if N is 1, MANY otherwise
+//	truncate N S              S cut to N runes, ending in "…" if cut
+//	default DEFAULT V         V, or DEFAULT if V is empty
+//	coalesce V...             the first V that is not empty
+//	dict KEY VALUE...         a map[string]any of pairs, for passing to partials
+//	list V...                 a []any of values
+//	url BASE KEY VALUE...     BASE with the pairs added to its query, escaped
+//	json V                    V encoded as JSON, safe within a <script> block
+//	number DECIMALS N         N with DECIMALS decimals and thousands separators
+//	T KEY ARGS...             KEY translated by a Localizer, formatted with ARGS
+//	locale                    the locale of a Localizer
+//
+// Arguments are ordered so that the last may be piped, as in
+// {{.Created | date "2006-01-02"}}. Values are empty if they are nil, zero or
+// of zero length. Without a Localizer, T returns KEY formatted with ARGS and
+// locale returns "".
+func FuncMap(funcs template.FuncMap) template.FuncMap {
+	fm := template.FuncMap{
+		"date":      formatDate,
+		"pluralize": pluralize,
+		"truncate":  truncate,
+		"default":   defaultValue,
+		"coalesce":  coalesce,
+		"dict":      dict,
+		"list":      func(values ...any) []any { return values },
+		"url":       buildURL,
+		"json":      embedJSON,
+		"number":    formatNumber,
+		"T":         func(key string, args ...any) string { return translate(key, args...) },
+		"locale":    func() string { return "" },
+	}
+	for name, fn := range funcs {
+		fm[name] = fn
+	}
+	return fm
+}
+
+func formatDate(layout string, t any) (string, error) {
+	switch t := t.(type) {
+	case time.Time:
+		if t.IsZero() {
+			return "", nil
+		}
+		return t.Format(layout), nil
+	case *time.Time:
+		if t == nil || t.IsZero() {
+			return "", nil
+		}
+		return t.Format(layout), nil
+	case nil:
+		return "", nil
+	}
+	return "", fmt.Errorf("date: unsupported type %T", t)
+}
+
+func pluralize(n any, one, many string) (string, error) {
+	f, err := toFloat(n)
+	if err != nil {
+		return "", fmt.Errorf("pluralize: %w", err)
+	}
+	if f == 1 {
+		return one, nil
+	}
+	return many, nil
+}
+
+func truncate(n int, s string) string {
+	if n < 0 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	if n == 0 {
+		return ""
+	}
+	r := []rune(s)
+	return strings.TrimRight(string(r[:n-1]), " ") + "…"
+}
+
+func defaultValue(def, v any) any {
+	if isEmpty(v) {
+		return def
+	}
+	return v
+}
+
+func coalesce(values ...any) any {
+	for _, v := range values {
+		if !isEmpty(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+func dict(pairs ...any) (map[string]any, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("dict: odd number of arguments")
+	}
+	m := make(map[string]any, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict: key %v is not a string", pairs[i])
+		}
+		m[key] = pairs[i+1]
+	}
+	return m, nil
+}
+
+func buildURL(base string, pairs ...any) (string, error) {
+	if len(pairs)%2 != 0 {
+		return "", fmt.Errorf("url: odd number of query arguments")
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("url: %w", err)
+	}
+	q := u.Query()
+	for i := 0; i < len(pairs); i += 2 {
+		q.Add(fmt.Sprint(pairs[i]), fmt.Sprint(pairs[i+1]))
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// embedJSON encodes v as JSON. The encoding escapes "<", ">", "&", U+2028 and
+// U+2029, so it is safe to embed verbatim in a script, as template.JS.
+func embedJSON(v any) (template.JS, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("json: %w", err)
+	}
+	return template.JS(b), nil
+}
+
+func formatNumber(decimals int, n any) (string, error) {
+	f, err := toFloat(n)
+	if err != nil {
+		return "", fmt.Errorf("number: %w", err)
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	}
+	s := strconv.FormatFloat(math.Abs(f), 'f', max(decimals, 0), 64)
+	whole, frac, _ := strings.Cut(s, ".")
+	var b strings.Builder
+	if f < 0 && strings.Trim(s, "0.") != "" {
+		b.WriteByte('-')
+	}
+	for i, c := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	if frac != "" {
+		b.WriteString("." + frac)
+	}
+	return b.String(), nil
+}
+
+// toFloat returns the number n as a float64.
+func toFloat(n any) (float64, error) {
+	v := reflect.ValueOf(n)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	}
+	return 0, fmt.Errorf("%v is not a number", n)
+}
+
+// isEmpty reports whether v is nil, the zero value of its type, or of zero
+// length.
+func isEmpty(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String, reflect.Chan:
+		return rv.Len() == 0
+	}
+	return rv.IsZero()
+}
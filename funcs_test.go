@@ -0,0 +1,70 @@
+package reply
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFuncMap(t *testing.T) {
+	created := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	cases := map[string]struct {
+		text    string
+		data    any
+		want    string
+		wantErr bool
+	}{
+		"date":                  {text: `{{.| date "2006-01-02"}}`, data: created, want: "2023-10-01"},
+		"date pointer":          {text: `{{.| date "Jan 2"}}`, data: &created, want: "Oct 1"},
+		"date zero":             {text: `{{.| date "2006"}}`, data: time.Time{}, want: ""},
+		"date unsupported":      {text: `{{.| date "2006"}}`, data: "today", wantErr: true},
+		"pluralize one":         {text: `{{pluralize . "item" "items"}}`, data: 1, want: "item"},
+		"pluralize many":        {text: `{{pluralize . "item" "items"}}`, data: uint8(3), want: "items"},
+		"pluralize len":         {text: `{{pluralize (len .) "item" "items"}}`, data: []int{}, want: "items"},
+		"truncate":              {text: `{{truncate 8 .}}`, data: "Hello, wonderful world", want: "Hello,…"},
+		"truncate runes":        {text: `{{truncate 3 .}}`, data: "héllo", want: "hé…"},
+		"truncate short":        {text: `{{truncate 8 .}}`, data: "Hello", want: "Hello"},
+		"default empty":         {text: `{{default "n/a" .}}`, data: "", want: "n/a"},
+		"default set":           {text: `{{default "n/a" .}}`, data: "Sherlock", want: "Sherlock"},
+		"default zero":          {text: `{{default 10 .}}`, data: 0, want: "10"},
+		"coalesce":              {text: `{{coalesce .Nick .Name "anonymous"}}`, data: map[string]string{"Name": "Sherlock"}, want: "Sherlock"},
+		"coalesce none":         {text: `{{coalesce "" 0}}`, want: ""},
+		"dict and list":         {text: `{{define "p"}}{{.title}}:{{range .items}}[{{.}}]{{end}}{{end}}{{template "p" dict "title" "Tags" "items" (list "a" "b")}}`, want: "Tags:[a][b]"},
+		"dict odd":              {text: `{{dict "title"}}`, wantErr: true},
+		"dict key":              {text: `{{dict 1 "one"}}`, wantErr: true},
+		"url":                   {text: `<a href="{{url "/search" "q" . "page" 2}}">`, data: "cats & dogs", want: `<a href="/search?page=2&amp;q=cats&#43;%26&#43;dogs">`},
+		"url keeps query":       {text: `{{url "/search?sort=asc" "q" "x"}}`, want: "/search?q=x&amp;sort=asc"},
+		"url odd":               {text: `{{url "/search" "q"}}`, wantErr: true},
+		"json in script":        {text: `<script>var data = {{json .}};</script>`, data: map[string]string{"html": "</script><b>"}, want: `<script>var data = {"html":"\u003c/script\u003e\u003cb\u003e"};</script>`},
+		"number":                {text: `{{number 2 .}}`, data: 1234567.891, want: "1,234,567.89"},
+		"number integer":        {text: `{{number 0 .}}`, data: -1234, want: "-1,234"},
+		"number small":          {text: `{{number 1 .}}`, data: 999, want: "999.0"},
+		"number negative zero":  {text: `{{number 1 .}}`, data: -0.01, want: "0.0"},
+		"number not a number":   {text: `{{number 0 .}}`, data: "12", wantErr: true},
+		"caller funcs override": {text: `{{truncate 1 .}}`, data: "Hello", want: "HELLO"},
+	}
+	funcs := template.FuncMap{
+		"truncate": func(_ int, s string) string { return strings.ToUpper(s) },
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			fm := FuncMap(nil)
+			if name == "caller funcs override" {
+				fm = FuncMap(funcs)
+			}
+			tmpl, err := template.New(name).Funcs(fm).Parse(c.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b strings.Builder
+			err = tmpl.Execute(&b, c.data)
+			if (err != nil) != c.wantErr {
+				t.Errorf(errorString, err, c.wantErr)
+			}
+			if got := b.String(); !c.wantErr && got != c.want {
+				t.Errorf(errorString, got, c.want)
+			}
+		})
+	}
+}
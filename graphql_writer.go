@@ -0,0 +1,67 @@
+package reply
+
+import (
+	"net/http"
+)
+
+// GraphQLError represents an error in a GraphQL response.
+type GraphQLError struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// GraphQLResult represents a GraphQL result with partial data. Use it as
+// Options.Data to reply with both data and errors.
+type GraphQLResult struct {
+	Data   any
+	Errors []GraphQLError
+}
+
+// GraphQLWriter implements Writer for GraphQL shaped JSON responses of the
+// form {"data": ..., "errors": [...]}.
+type GraphQLWriter struct {
+	JSONWriter
+}
+
+// graphqlResponse is the document encoded by a GraphQLWriter.
+type graphqlResponse struct {
+	Data   any            `json:"data"`
+	Errors []GraphQLError `json:"errors,omitempty"`
+}
+
+// Reply sends an HTTP status response header with the given status code and
+// writes a GraphQL response to w with opts.Data as its data. If opts.Data is a
+// GraphQLResult, its errors are included and given the status code as their
+// "code" extension unless they already have one. If an error occurs at
+// encoding, the function exits and does not write to w.
+func (gw GraphQLWriter) Reply(w http.ResponseWriter, code int, opts Options) error {
+	res := graphqlResponse{Data: opts.Data}
+	var result *GraphQLResult
+	switch v := opts.Data.(type) {
+	case GraphQLResult:
+		result = &v
+	case *GraphQLResult:
+		result = v
+	}
+	if result != nil {
+		res.Data = result.Data
+		for _, e := range result.Errors {
+			ext := map[string]any{"code": code}
+			for k, v := range e.Extensions {
+				ext[k] = v
+			}
+			e.Extensions = ext
+			res.Errors = append(res.Errors, e)
+		}
+	}
+	return gw.encode(w, code, "application/json", res, opts)
+}
+
+// Error sends an HTTP response header with the given status code and writes
+// a GraphQL response with null data and error as its single error to w.
+func (gw GraphQLWriter) Error(w http.ResponseWriter, error string, code int) {
+	_ = gw.encode(w, code, "application/json", graphqlResponse{
+		Errors: []GraphQLError{{Message: error, Extensions: map[string]any{"code": code}}},
+	}, Options{})
+}
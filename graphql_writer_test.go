@@ -0,0 +1,97 @@
+package reply
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGraphQLReply(t *testing.T) {
+	cases := map[string]struct {
+		code     int
+		opts     Options
+		wantErr  bool
+		wantBody string
+	}{
+		"error - fail encode": {
+			code:    http.StatusOK,
+			opts:    Options{Data: map[string]any{"foo": make(chan int)}},
+			wantErr: true,
+		},
+		"null data": {
+			code:     http.StatusOK,
+			opts:     Options{},
+			wantBody: `{"data":null}`,
+		},
+		"data": {
+			code:     http.StatusOK,
+			opts:     Options{Data: map[string]string{"name": "Sherlock"}},
+			wantBody: `{"data":{"name":"Sherlock"}}`,
+		},
+		"partial data": {
+			code: http.StatusOK,
+			opts: Options{Data: GraphQLResult{
+				Data: map[string]any{"user": map[string]any{"name": "Sherlock", "friends": nil}},
+				Errors: []GraphQLError{
+					{Message: "friends unavailable", Path: []any{"user", "friends"}},
+					{Message: "rate limited", Extensions: map[string]any{"code": "RATE_LIMITED"}},
+				},
+			}},
+			wantBody: `{"data":{"user":{"friends":null,"name":"Sherlock"}},"errors":[{"message":"friends unavailable","path":["user","friends"],"extensions":{"code":200}},{"message":"rate limited","extensions":{"code":"RATE_LIMITED"}}]}`,
+		},
+		"result pointer": {
+			code:     http.StatusBadGateway,
+			opts:     Options{Data: &GraphQLResult{Errors: []GraphQLError{{Message: "upstream"}}}},
+			wantBody: `{"data":null,"errors":[{"message":"upstream","extensions":{"code":502}}]}`,
+		},
+	}
+	gw := GraphQLWriter{}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			err := gw.Reply(w, c.code, c.opts)
+			if (err != nil) != c.wantErr {
+				t.Errorf(errorString, err, c.wantErr)
+			}
+			if c.wantErr {
+				return
+			}
+			if got := w.Code; got != c.code {
+				t.Errorf(errorString, got, c.code)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != c.wantBody {
+				t.Errorf(errorString, got, c.wantBody)
+			}
+		})
+	}
+}
+
+func TestGraphQLError(t *testing.T) {
+	cases := map[string]struct {
+		code int
+		want string
+	}{
+		"bad request": {
+			code: http.StatusBadRequest,
+			want: `{"data":null,"errors":[{"message":"Bad Request","extensions":{"code":400}}]}`,
+		},
+		"not found": {
+			code: http.StatusNotFound,
+			want: `{"data":null,"errors":[{"message":"Not Found","extensions":{"code":404}}]}`,
+		},
+	}
+	gw := GraphQLWriter{}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			gw.Error(w, http.StatusText(c.code), c.code)
+			if got := w.Code; got != c.code {
+				t.Errorf(errorString, got, c.code)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != c.want {
+				t.Errorf(errorString, got, c.want)
+			}
+		})
+	}
+}
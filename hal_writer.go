@@ -0,0 +1,171 @@
+package reply
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Link represents a hypermedia link to a resource.
+type Link struct {
+	Href      string `json:"href"`
+	Templated bool   `json:"templated,omitempty"`
+	Type      string `json:"type,omitempty"`
+	Title     string `json:"title,omitempty"`
+}
+
+// Links represents hypermedia links by relation.
+type Links map[string]Link
+
+// Linker is optionally implemented by data rendered by a HALWriter to define
+// its links by relation.
+type Linker interface {
+	Links() map[string]Link
+}
+
+// Embedder is optionally implemented by data rendered by a HALWriter to
+// define its embedded resources by relation. Values are a resource or a slice
+// of resources.
+type Embedder interface {
+	Embedded() map[string]any
+}
+
+// HALWriter implements Writer for HAL (application/hal+json) responses.
+//
+// A HALWriter renders Options.Data as a resource with its "_links" and
+// "_embedded" defined by Linker and Embedder, and Options.Links. If Data is a
+// slice, it is rendered as a collection resource embedding its elements.
+type HALWriter struct {
+	JSONWriter
+
+	// CollectionRel defines the relation of the elements embedded in a
+	// collection resource. If empty, "items" is used.
+	CollectionRel string
+
+	// ErrorDocs defines an optional documentation URL linked as "help" from
+	// error resources. Occurrences of "{code}" are replaced with the status
+	// code of the error.
+	ErrorDocs string
+}
+
+// Reply sends an HTTP status response header with the given status code and
+// writes opts.Data as a HAL resource to w. Links in opts, and pagination links
+// if opts has Pagination, are added to the resource. If an error occurs at
+// encoding, the function exits and does not write to w.
+func (hw HALWriter) Reply(w http.ResponseWriter, code int, opts Options) error {
+	var res map[string]any
+	if rv := reflect.ValueOf(opts.Data); rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		items, err := halResources(rv)
+		if err != nil {
+			return err
+		}
+		rel := hw.CollectionRel
+		if rel == "" {
+			rel = "items"
+		}
+		res = map[string]any{"_embedded": map[string]any{rel: items}}
+		if pg := opts.Pagination; pg != nil && pg.Total != nil {
+			res["total"] = *pg.Total
+		}
+	} else if opts.Data != nil {
+		var err error
+		if res, err = halResource(opts.Data); err != nil {
+			return err
+		}
+	} else {
+		res = map[string]any{}
+	}
+	links := map[string]Link{}
+	if l, ok := res["_links"].(map[string]Link); ok {
+		for rel, link := range l {
+			links[rel] = link
+		}
+	}
+	if pg := opts.Pagination; pg != nil {
+		for rel, href := range map[string]string{"first": pg.First, "prev": pg.Prev, "next": pg.Next, "last": pg.Last} {
+			if href != "" {
+				links[rel] = Link{Href: href}
+			}
+		}
+	}
+	if opts.Links != nil {
+		for rel, link := range *opts.Links {
+			links[rel] = link
+		}
+	}
+	if len(links) > 0 {
+		res["_links"] = links
+	}
+	return hw.encode(w, code, "application/hal+json", res, opts)
+}
+
+// Error sends an HTTP response header with the given status code and writes
+// a HAL error resource to w, linking to ErrorDocs as "help" if set.
+func (hw HALWriter) Error(w http.ResponseWriter, error string, code int) {
+	res := map[string]any{"status": code, "message": error}
+	if hw.ErrorDocs != "" {
+		href := strings.ReplaceAll(hw.ErrorDocs, "{code}", strconv.Itoa(code))
+		res["_links"] = map[string]Link{"help": {Href: href}}
+	}
+	_ = hw.encode(w, code, "application/hal+json", res, Options{})
+}
+
+// halResource returns v as a HAL resource: the properties of its JSON object
+// encoding with its links and embedded resources.
+func halResource(v any) (map[string]any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	props := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &props); err != nil {
+		return nil, fmt.Errorf("hal resource %T must encode to a JSON object", v)
+	}
+	res := make(map[string]any, len(props)+2)
+	for k, p := range props {
+		res[k] = p
+	}
+	if l, ok := v.(Linker); ok {
+		if links := l.Links(); len(links) > 0 {
+			res["_links"] = links
+		}
+	}
+	if e, ok := v.(Embedder); ok {
+		embedded := map[string]any{}
+		for rel, ev := range e.Embedded() {
+			rv := reflect.ValueOf(ev)
+			var err error
+			switch {
+			case !rv.IsValid():
+				continue
+			case rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array:
+				embedded[rel], err = halResources(rv)
+			default:
+				embedded[rel], err = halResource(ev)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(embedded) > 0 {
+			res["_embedded"] = embedded
+		}
+	}
+	return res, nil
+}
+
+// halResources returns the elements of rv, a slice or array, as HAL resources.
+func halResources(rv reflect.Value) ([]map[string]any, error) {
+	items := make([]map[string]any, rv.Len())
+	for i := range items {
+		item, err := halResource(rv.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		items[i] = item
+	}
+	return items, nil
+}
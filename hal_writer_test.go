@@ -0,0 +1,120 @@
+package reply
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type order struct {
+	ID    int        `json:"id"`
+	Items []lineItem `json:"-"`
+}
+
+func (o order) Links() map[string]Link {
+	return map[string]Link{"self": {Href: fmt.Sprintf("/orders/%d", o.ID)}}
+}
+
+func (o order) Embedded() map[string]any {
+	return map[string]any{"items": o.Items, "customer": nil}
+}
+
+type lineItem struct {
+	Name string `json:"name"`
+}
+
+func TestHALReply(t *testing.T) {
+	o := order{ID: 1, Items: []lineItem{{Name: "pipe"}}}
+	cases := map[string]struct {
+		writer   HALWriter
+		opts     Options
+		wantErr  bool
+		wantBody string
+	}{
+		"error - fail encode": {
+			opts:    Options{Data: map[string]any{"foo": make(chan int)}},
+			wantErr: true,
+		},
+		"error - not an object": {
+			opts:    Options{Data: "Sherlock"},
+			wantErr: true,
+		},
+		"null data": {
+			opts:     Options{},
+			wantBody: `{}`,
+		},
+		"resource with links and embedded": {
+			opts:     Options{Data: o},
+			wantBody: `{"_embedded":{"items":[{"name":"pipe"}]},"_links":{"self":{"href":"/orders/1"}},"id":1}`,
+		},
+		"options links": {
+			opts: Options{
+				Data:  o,
+				Links: &Links{"self": {Href: "/orders/one"}, "find": {Href: "/orders{?id}", Templated: true}},
+			},
+			wantBody: `{"_embedded":{"items":[{"name":"pipe"}]},"_links":{"find":{"href":"/orders{?id}","templated":true},"self":{"href":"/orders/one"}},"id":1}`,
+		},
+		"collection": {
+			opts: Options{
+				Data:       []order{o},
+				Pagination: &Pagination{Total: new(int), Next: "/orders?cursor=b"},
+			},
+			wantBody: `{"_embedded":{"items":[{"_embedded":{"items":[{"name":"pipe"}]},"_links":{"self":{"href":"/orders/1"}},"id":1}]},"_links":{"next":{"href":"/orders?cursor=b"}},"total":0}`,
+		},
+		"collection; rel": {
+			writer:   HALWriter{CollectionRel: "orders"},
+			opts:     Options{Data: []lineItem{}},
+			wantBody: `{"_embedded":{"orders":[]}}`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			err := c.writer.Reply(w, http.StatusOK, c.opts)
+			if (err != nil) != c.wantErr {
+				t.Errorf(errorString, err, c.wantErr)
+			}
+			if c.wantErr {
+				return
+			}
+			if got, want := w.Header().Get("Content-Type"), "application/hal+json"; got != want {
+				t.Errorf(errorString, got, want)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != c.wantBody {
+				t.Errorf(errorString, got, c.wantBody)
+			}
+		})
+	}
+}
+
+func TestHALError(t *testing.T) {
+	cases := map[string]struct {
+		writer HALWriter
+		code   int
+		want   string
+	}{
+		"no docs": {
+			code: http.StatusNotFound,
+			want: `{"message":"Not Found","status":404}`,
+		},
+		"docs": {
+			writer: HALWriter{ErrorDocs: "https://example.com/errors/{code}"},
+			code:   http.StatusConflict,
+			want:   `{"_links":{"help":{"href":"https://example.com/errors/409"}},"message":"Conflict","status":409}`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			Engine{Writer: c.writer}.Error(w, http.StatusText(c.code), c.code)
+			if got := w.Code; got != c.code {
+				t.Errorf(errorString, got, c.code)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != c.want {
+				t.Errorf(errorString, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package reply
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+	"text/template/parse"
+)
+
+// DefaultPartialHeader is the request header of htmx marking a partial
+// request, used by a TemplateWriter without a PartialHeader.
+const DefaultPartialHeader = "HX-Request"
+
+// partialName returns the name of the template executed for opts: tw's
+// PartialName if opts.Request is a partial request and the template of opts
+// renders the partial, or opts.TemplateName. Boosted and history restore
+// requests of htmx are replied to in full.
+func (tw *TemplateWriter) partialName(tmpl *template.Template, opts Options) string {
+	if !tw.hasPartial(tmpl, opts) {
+		return opts.TemplateName
+	}
+	h := opts.Request.Header
+	if h.Get(tw.partialHeader()) == "" || h.Get("HX-Boosted") == "true" || h.Get("HX-History-Restore-Request") == "true" {
+		return opts.TemplateName
+	}
+	return tw.PartialName
+}
+
+// hasPartial reports whether the reply of opts may be replaced by tw's
+// PartialName: the template executed for opts, such as a layout, renders the
+// partial, and opts does not ask for a full page. An explicit TemplateName of
+// a fragment not rendering the partial is always executed as is.
+func (tw *TemplateWriter) hasPartial(tmpl *template.Template, opts Options) bool {
+	if tw.PartialName == "" || opts.Request == nil || opts.FullPage {
+		return false
+	}
+	if tmpl.Lookup(tw.PartialName) == nil {
+		return false
+	}
+	return invokes(tmpl, opts.TemplateName, tw.PartialName)
+}
+
+// invokes reports whether the template name of tmpl, or tmpl itself if name
+// is empty, invokes the template target, directly or through the templates
+// it invokes.
+func invokes(tmpl *template.Template, name, target string) bool {
+	if name != "" {
+		if tmpl = tmpl.Lookup(name); tmpl == nil {
+			return false
+		}
+	}
+	if tmpl.Tree == nil {
+		return false
+	}
+	seen := map[string]bool{}
+	var walk func(n parse.Node) bool
+	walk = func(n parse.Node) bool {
+		switch n := n.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return false
+			}
+			for _, c := range n.Nodes {
+				if walk(c) {
+					return true
+				}
+			}
+		case *parse.IfNode:
+			return walk(n.List) || walk(n.ElseList)
+		case *parse.RangeNode:
+			return walk(n.List) || walk(n.ElseList)
+		case *parse.WithNode:
+			return walk(n.List) || walk(n.ElseList)
+		case *parse.TemplateNode:
+			if n.Name == target {
+				return true
+			}
+			if seen[n.Name] {
+				return false
+			}
+			seen[n.Name] = true
+			if t := tmpl.Lookup(n.Name); t != nil && t.Tree != nil {
+				return walk(t.Tree.Root)
+			}
+		}
+		return false
+	}
+	return walk(tmpl.Tree.Root)
+}
+
+// partialHeader returns the request header marking a partial request.
+func (tw *TemplateWriter) partialHeader() string {
+	if tw.PartialHeader == "" {
+		return DefaultPartialHeader
+	}
+	return tw.PartialHeader
+}
+
+// execute executes the template name of tmpl, or tmpl itself if name is
+// empty, followed by each of the comma-separated named templates oob.
+func execute(w io.Writer, tmpl *template.Template, name string, oob string, data any) error {
+	var err error
+	if name != "" {
+		err = tmpl.ExecuteTemplate(w, name, data)
+	} else {
+		err = tmpl.Execute(w, data)
+	}
+	for _, name := range strings.Split(oob, ",") {
+		if err != nil {
+			break
+		}
+		if name = strings.TrimSpace(name); name != "" {
+			err = tmpl.ExecuteTemplate(w, name, data)
+		}
+	}
+	return err
+}
+
+// HXRedirect sets the HX-Redirect response header, making htmx redirect the
+// browser to url with a full page load.
+func HXRedirect(w http.ResponseWriter, url string) {
+	w.Header().Set("HX-Redirect", url)
+}
+
+// HXLocation sets the HX-Location response header, making htmx navigate to
+// url without a full page load.
+func HXLocation(w http.ResponseWriter, url string) {
+	w.Header().Set("HX-Location", url)
+}
+
+// HXRetarget sets the HX-Retarget response header, making htmx swap the
+// reply into the elements matching the CSS selector instead of its target.
+func HXRetarget(w http.ResponseWriter, selector string) {
+	w.Header().Set("HX-Retarget", selector)
+}
+
+// HXReswap sets the HX-Reswap response header, overriding how htmx swaps the
+// reply, such as "outerHTML".
+func HXReswap(w http.ResponseWriter, swap string) {
+	w.Header().Set("HX-Reswap", swap)
+}
+
+// HXTrigger sets the HX-Trigger response header, making htmx trigger the
+// client-side events once the reply is received.
+func HXTrigger(w http.ResponseWriter, events ...string) {
+	w.Header().Set("HX-Trigger", strings.Join(events, ", "))
+}
+
+// HXTriggerDetails sets the HX-Trigger response header like HXTrigger, with
+// the details of each event by name, encoded as JSON.
+func HXTriggerDetails(w http.ResponseWriter, events map[string]any) error {
+	b, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("HX-Trigger", string(b))
+	return nil
+}
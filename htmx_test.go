@@ -0,0 +1,181 @@
+package reply
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPartial(t *testing.T) {
+	page := template.Must(template.New("page").Parse(
+		`{{define "base"}}<html>{{template "body" .}}</html>{{end}}` +
+			`{{define "body"}}{{if .}}{{template "content" .}}{{end}}{{end}}` +
+			`{{define "content"}}<main>{{.}}</main>{{end}}` +
+			`{{define "count"}}<span id="count" hx-swap-oob="true">3</span>{{end}}` +
+			`{{define "flash"}}<div id="flash" hx-swap-oob="true">Saved</div>{{end}}`,
+	))
+	plain := template.Must(template.New("plain").Parse(`<p>{{.}}</p>`))
+	cases := map[string]struct {
+		writer   TemplateWriter
+		header   map[string]string
+		opts     Options
+		wantVary string
+		wantBody string
+	}{
+		"full page": {
+			writer:   TemplateWriter{PartialName: "content"},
+			opts:     Options{TemplateName: "base"},
+			wantVary: "HX-Request",
+			wantBody: "<html><main>Sherlock</main></html>",
+		},
+		"partial": {
+			writer:   TemplateWriter{PartialName: "content"},
+			header:   map[string]string{"HX-Request": "true"},
+			opts:     Options{TemplateName: "base"},
+			wantVary: "HX-Request",
+			wantBody: "<main>Sherlock</main>",
+		},
+		"partial; boosted": {
+			writer:   TemplateWriter{PartialName: "content"},
+			header:   map[string]string{"HX-Request": "true", "HX-Boosted": "true"},
+			opts:     Options{TemplateName: "base"},
+			wantVary: "HX-Request",
+			wantBody: "<html><main>Sherlock</main></html>",
+		},
+		"partial; history restore": {
+			writer:   TemplateWriter{PartialName: "content"},
+			header:   map[string]string{"HX-Request": "true", "HX-History-Restore-Request": "true"},
+			opts:     Options{TemplateName: "base"},
+			wantVary: "HX-Request",
+			wantBody: "<html><main>Sherlock</main></html>",
+		},
+		"partial; custom header": {
+			writer:   TemplateWriter{PartialName: "content", PartialHeader: "Turbo-Frame"},
+			header:   map[string]string{"Turbo-Frame": "main"},
+			opts:     Options{TemplateName: "base"},
+			wantVary: "Turbo-Frame",
+			wantBody: "<main>Sherlock</main>",
+		},
+		"partial disabled": {
+			header:   map[string]string{"HX-Request": "true"},
+			opts:     Options{TemplateName: "base"},
+			wantBody: "<html><main>Sherlock</main></html>",
+		},
+		"partial with oob": {
+			writer:   TemplateWriter{PartialName: "content"},
+			header:   map[string]string{"HX-Request": "true"},
+			opts:     Options{TemplateName: "base", OOB: "count, flash"},
+			wantVary: "HX-Request",
+			wantBody: `<main>Sherlock</main><span id="count" hx-swap-oob="true">3</span><div id="flash" hx-swap-oob="true">Saved</div>`,
+		},
+		"partial; explicit fragment": {
+			writer:   TemplateWriter{PartialName: "content"},
+			header:   map[string]string{"HX-Request": "true"},
+			opts:     Options{TemplateName: "count"},
+			wantBody: `<span id="count" hx-swap-oob="true">3</span>`,
+		},
+		"partial; template without partial": {
+			writer:   TemplateWriter{PartialName: "content"},
+			header:   map[string]string{"HX-Request": "true"},
+			opts:     Options{TemplateKey: "plain"},
+			wantBody: "<p>Sherlock</p>",
+		},
+		"partial; full page": {
+			writer:   TemplateWriter{PartialName: "content"},
+			header:   map[string]string{"HX-Request": "true"},
+			opts:     Options{TemplateName: "base", FullPage: true},
+			wantBody: "<html><main>Sherlock</main></html>",
+		},
+		"oob only": {
+			opts:     Options{TemplateName: "count", OOB: "flash"},
+			wantBody: `<span id="count" hx-swap-oob="true">3</span><div id="flash" hx-swap-oob="true">Saved</div>`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range c.header {
+				r.Header.Set(k, v)
+			}
+			c.writer.Templates = map[string]*template.Template{"page": page, "plain": plain}
+			if c.opts.TemplateKey == "" {
+				c.opts.TemplateKey = "page"
+			}
+			c.opts.Data, c.opts.Request = "Sherlock", r
+			w := httptest.NewRecorder()
+			if err := c.writer.Reply(w, http.StatusOK, c.opts); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Header().Get("Vary"); got != c.wantVary {
+				t.Errorf(errorString, got, c.wantVary)
+			}
+			if got := w.Body.String(); got != c.wantBody {
+				t.Errorf(errorString, got, c.wantBody)
+			}
+		})
+	}
+}
+
+func TestOOBError(t *testing.T) {
+	page := template.Must(template.New("page").Parse(`main`))
+	tw := &TemplateWriter{Templates: map[string]*template.Template{"page": page}}
+	w := httptest.NewRecorder()
+	err := tw.Reply(w, http.StatusOK, Options{TemplateKey: "page", OOB: "missing"})
+	if err == nil {
+		t.Errorf(errorString, err, "an error")
+	}
+	if got := w.Body.String(); got != "" {
+		t.Errorf(errorString, got, "")
+	}
+}
+
+func TestHXHeaders(t *testing.T) {
+	cases := map[string]struct {
+		set    func(w http.ResponseWriter)
+		header string
+		want   string
+	}{
+		"redirect": {
+			set:    func(w http.ResponseWriter) { HXRedirect(w, "/login") },
+			header: "HX-Redirect",
+			want:   "/login",
+		},
+		"location": {
+			set:    func(w http.ResponseWriter) { HXLocation(w, "/users") },
+			header: "HX-Location",
+			want:   "/users",
+		},
+		"retarget": {
+			set:    func(w http.ResponseWriter) { HXRetarget(w, "#errors") },
+			header: "HX-Retarget",
+			want:   "#errors",
+		},
+		"reswap": {
+			set:    func(w http.ResponseWriter) { HXReswap(w, "outerHTML") },
+			header: "HX-Reswap",
+			want:   "outerHTML",
+		},
+		"trigger": {
+			set:    func(w http.ResponseWriter) { HXTrigger(w, "saved", "refresh") },
+			header: "HX-Trigger",
+			want:   "saved, refresh",
+		},
+		"trigger details": {
+			set: func(w http.ResponseWriter) {
+				_ = HXTriggerDetails(w, map[string]any{"saved": map[string]int{"id": 7}, "refresh": nil})
+			},
+			header: "HX-Trigger",
+			want:   `{"refresh":null,"saved":{"id":7}}`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c.set(w)
+			if got := w.Header().Get(c.header); got != c.want {
+				t.Errorf(errorString, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package reply
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Catalog provides translated messages by locale.
+type Catalog interface {
+	// Message returns the message of key in locale, and whether it exists.
+	Message(locale, key string) (string, bool)
+}
+
+// MapCatalog is a Catalog of messages by key, by locale.
+type MapCatalog map[string]map[string]string
+
+// Message returns the message of key in locale, and whether it exists.
+func (mc MapCatalog) Message(locale, key string) (string, bool) {
+	msg, ok := mc[locale][key]
+	return msg, ok
+}
+
+// Localizer selects the locale of requests and translates messages to it. A
+// TemplateWriter with a Localizer replies with the template variant of the
+// locale, such as "home.fr.html" for "home.html", and binds the template's T
+// and locale functions to it. An Engine with a Localizer translates the
+// error messages of its WithRequest copies, such as "Not Found", using them
+// as keys. A Localizer must not be copied after first use.
+type Localizer struct {
+	// Locales defines the supported locales, such as "en" or "fr-CA". The
+	// first is the default.
+	Locales []string
+
+	// Cookie defines the optional name of a cookie holding a chosen locale,
+	// which takes precedence over the Accept-Language header.
+	Cookie string
+
+	// Catalog defines the translated messages.
+	Catalog Catalog
+
+	mu     sync.Mutex
+	clones map[localized]localizedClone
+}
+
+// localized identifies a template key localized to a locale.
+type localized struct {
+	key    string
+	locale string
+}
+
+// localizedClone is a clone of the template src bound to a locale.
+type localizedClone struct {
+	src   *template.Template
+	clone *template.Template
+}
+
+// Locale returns the supported locale of r, chosen by l's Cookie, then by
+// the Accept-Language header, then the default locale.
+func (l *Localizer) Locale(r *http.Request) string {
+	if r != nil && l.Cookie != "" {
+		if c, err := r.Cookie(l.Cookie); err == nil {
+			if locale := l.match(c.Value); locale != "" {
+				return locale
+			}
+		}
+	}
+	if r != nil {
+		best, bestQ := "", 0.0
+		for _, accept := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+			tag, params, _ := strings.Cut(accept, ";")
+			q := 1.0
+			if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+			if locale := l.match(tag); locale != "" && q > bestQ {
+				best, bestQ = locale, q
+			}
+		}
+		if best != "" {
+			return best
+		}
+	}
+	return l.defaultLocale()
+}
+
+// match returns the supported locale matching the language tag, or one of
+// the same language, or "" if none is supported.
+func (l *Localizer) match(tag string) string {
+	tag = strings.TrimSpace(tag)
+	if tag == "" || tag == "*" {
+		return ""
+	}
+	for _, locale := range l.Locales {
+		if strings.EqualFold(locale, tag) {
+			return locale
+		}
+	}
+	for _, locale := range l.Locales {
+		if strings.EqualFold(language(locale), language(tag)) {
+			return locale
+		}
+	}
+	return ""
+}
+
+// defaultLocale returns l's first locale, or "" if it has none.
+func (l *Localizer) defaultLocale() string {
+	if len(l.Locales) == 0 {
+		return ""
+	}
+	return l.Locales[0]
+}
+
+// T returns the message of key translated to locale, or to its language, or
+// key itself if l's Catalog has neither. If args are given, the message is
+// used as a format for them, as in fmt.Sprintf.
+func (l *Localizer) T(locale, key string, args ...any) string {
+	msg := key
+	if l.Catalog != nil {
+		if m, ok := l.Catalog.Message(locale, key); ok {
+			msg = m
+		} else if m, ok := l.Catalog.Message(language(locale), key); ok {
+			msg = m
+		}
+	}
+	return translate(msg, args...)
+}
+
+// template returns a clone of tmpl, the template of key, whose T and locale
+// functions are bound to locale. Clones are cached by key and locale, and
+// replaced once the template of key changes, as when templates are reloaded.
+// Cloning fails if tmpl has been executed, so tmpl itself is never executed.
+func (l *Localizer) template(key string, tmpl *template.Template, locale string) (*template.Template, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	k := localized{key: key, locale: locale}
+	if c, ok := l.clones[k]; ok && c.src == tmpl {
+		return c.clone, nil
+	}
+	clone, err := tmpl.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("localize template '%s': %w", key, err)
+	}
+	clone.Funcs(template.FuncMap{
+		"T":      func(key string, args ...any) string { return l.T(locale, key, args...) },
+		"locale": func() string { return locale },
+	})
+	if l.clones == nil {
+		l.clones = map[localized]localizedClone{}
+	}
+	l.clones[k] = localizedClone{src: tmpl, clone: clone}
+	return clone, nil
+}
+
+// localizedKeys returns the template keys of the variants of key for locale
+// in order of preference, such as "home.fr-CA.html", "home.fr.html" and
+// "home.html".
+func localizedKeys(key, locale string) []string {
+	if locale == "" {
+		return []string{key}
+	}
+	ext := path.Ext(key)
+	base := strings.TrimSuffix(key, ext)
+	keys := []string{base + "." + locale + ext}
+	if lang := language(locale); lang != locale {
+		keys = append(keys, base+"."+lang+ext)
+	}
+	return append(keys, key)
+}
+
+// language returns the primary language subtag of a language tag, such as
+// "fr" for "fr-CA".
+func language(tag string) string {
+	lang, _, _ := strings.Cut(tag, "-")
+	return lang
+}
+
+// translate formats msg with args, if any.
+func translate(msg string, args ...any) string {
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
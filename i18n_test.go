@@ -0,0 +1,214 @@
+package reply
+
+import (
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var catalog = MapCatalog{
+	"fr":    {"Hello, %s": "Bonjour, %s", "Goodbye": "Au revoir", "Not Found": "Introuvable", "Internal Server Error": "Erreur interne"},
+	"fr-CA": {"Hello, %s": "Allô, %s"},
+}
+
+func TestLocale(t *testing.T) {
+	l := &Localizer{Locales: []string{"en", "fr", "fr-CA", "de"}, Cookie: "lang"}
+	cases := map[string]struct {
+		header map[string]string
+		cookie string
+		want   string
+	}{
+		"default":               {want: "en"},
+		"accept-language":       {header: map[string]string{"Accept-Language": "de"}, want: "de"},
+		"accept-language exact": {header: map[string]string{"Accept-Language": "fr-ca"}, want: "fr-CA"},
+		"accept-language base":  {header: map[string]string{"Accept-Language": "fr-BE"}, want: "fr"},
+		"accept-language q":     {header: map[string]string{"Accept-Language": "es, de;q=0.5, fr;q=0.8"}, want: "fr"},
+		"accept-language order": {header: map[string]string{"Accept-Language": "de, fr"}, want: "de"},
+		"accept-language none":  {header: map[string]string{"Accept-Language": "es, *;q=0.1"}, want: "en"},
+		"accept-language q=0":   {header: map[string]string{"Accept-Language": "fr;q=0"}, want: "en"},
+		"cookie":                {header: map[string]string{"Accept-Language": "de"}, cookie: "fr", want: "fr"},
+		"cookie unsupported":    {header: map[string]string{"Accept-Language": "de"}, cookie: "es", want: "de"},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range c.header {
+				r.Header.Set(k, v)
+			}
+			if c.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: "lang", Value: c.cookie})
+			}
+			if got := l.Locale(r); got != c.want {
+				t.Errorf(errorString, got, c.want)
+			}
+		})
+	}
+}
+
+func TestLocalizerT(t *testing.T) {
+	l := &Localizer{Locales: []string{"en", "fr", "fr-CA"}, Catalog: catalog}
+	cases := map[string]struct {
+		locale string
+		key    string
+		args   []any
+		want   string
+	}{
+		"translated":         {locale: "fr", key: "Hello, %s", args: []any{"Sherlock"}, want: "Bonjour, Sherlock"},
+		"region":             {locale: "fr-CA", key: "Hello, %s", args: []any{"Sherlock"}, want: "Allô, Sherlock"},
+		"language fallback":  {locale: "fr-CA", key: "Goodbye", want: "Au revoir"},
+		"key fallback":       {locale: "en", key: "Goodbye", want: "Goodbye"},
+		"key fallback; args": {locale: "de", key: "%d items", args: []any{3}, want: "3 items"},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := l.T(c.locale, c.key, c.args...); got != c.want {
+				t.Errorf(errorString, got, c.want)
+			}
+		})
+	}
+}
+
+func TestLocalizedTemplates(t *testing.T) {
+	parse := func(name, text string) *template.Template {
+		return template.Must(template.New(name).Funcs(FuncMap(nil)).Parse(text))
+	}
+	templates := map[string]*template.Template{
+		"home.html":    parse("home.html", `<p lang="{{locale}}">{{T "Hello, %s" .}}</p>`),
+		"home.fr.html": parse("home.fr.html", `<p lang="{{locale}}">Accueil: {{T "Hello, %s" .}}</p>`),
+		"500.html":     parse("500.html", `<p lang="{{locale}}">{{.Message}}</p>`),
+		"500.fr.html":  parse("500.fr.html", `<p lang="{{locale}}">FR {{.Message}}</p>`),
+	}
+	l := &Localizer{Locales: []string{"en", "fr", "fr-CA", "de"}, Catalog: catalog}
+	tw := NewTemplateWriter(templates)
+	tw.Localizer = l
+	e := Engine{Localizer: l, Writer: tw}
+	cases := map[string]struct {
+		language string
+		reply    func(e Engine, w http.ResponseWriter)
+		wantCode int
+		wantBody string
+	}{
+		"default locale": {
+			reply:    func(e Engine, w http.ResponseWriter) { e.OK(w, Options{TemplateKey: "home.html", Data: "Sherlock"}) },
+			wantCode: http.StatusOK,
+			wantBody: `<p lang="en">Hello, Sherlock</p>`,
+		},
+		"locale variant": {
+			language: "fr",
+			reply:    func(e Engine, w http.ResponseWriter) { e.OK(w, Options{TemplateKey: "home.html", Data: "Sherlock"}) },
+			wantCode: http.StatusOK,
+			wantBody: `<p lang="fr">Accueil: Bonjour, Sherlock</p>`,
+		},
+		"language variant": {
+			language: "fr-CA",
+			reply:    func(e Engine, w http.ResponseWriter) { e.OK(w, Options{TemplateKey: "home.html", Data: "Sherlock"}) },
+			wantCode: http.StatusOK,
+			wantBody: `<p lang="fr-CA">Accueil: Allô, Sherlock</p>`,
+		},
+		"no variant": {
+			language: "de",
+			reply:    func(e Engine, w http.ResponseWriter) { e.OK(w, Options{TemplateKey: "home.html", Data: "Sherlock"}) },
+			wantCode: http.StatusOK,
+			wantBody: `<p lang="de">Hello, Sherlock</p>`,
+		},
+		"error message": {
+			language: "fr",
+			reply:    func(e Engine, w http.ResponseWriter) { e.NotFound(w) },
+			wantCode: http.StatusNotFound,
+			wantBody: "<p>Introuvable</p>",
+		},
+		"error message untranslated": {
+			language: "de",
+			reply:    func(e Engine, w http.ResponseWriter) { e.NotFound(w) },
+			wantCode: http.StatusNotFound,
+			wantBody: "<p>Not Found</p>",
+		},
+		"reply error message": {
+			language: "de",
+			reply:    func(e Engine, w http.ResponseWriter) { e.OK(w, Options{TemplateKey: "missing.html"}) },
+			wantCode: http.StatusInternalServerError,
+			wantBody: `<p lang="de">Internal Server Error</p>`,
+		},
+		"error template variant": {
+			language: "fr",
+			reply:    func(e Engine, w http.ResponseWriter) { e.InternalServerError(w) },
+			wantCode: http.StatusInternalServerError,
+			wantBody: `<p lang="fr">FR Erreur interne</p>`,
+		},
+		"error details template variant": {
+			language: "fr",
+			reply: func(e Engine, w http.ResponseWriter) {
+				e.ErrorDetails(w, "Internal Server Error", http.StatusInternalServerError, nil)
+			},
+			wantCode: http.StatusInternalServerError,
+			wantBody: `<p lang="fr">FR Erreur interne</p>`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Language", c.language)
+			w := httptest.NewRecorder()
+			c.reply(e.WithRequest(r), w)
+			if got := w.Code; got != c.wantCode {
+				t.Errorf(errorString, got, c.wantCode)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != c.wantBody {
+				t.Errorf(errorString, got, c.wantBody)
+			}
+		})
+	}
+}
+
+func TestLocalizedErrorJSON(t *testing.T) {
+	l := &Localizer{Locales: []string{"en", "fr"}, Catalog: catalog}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Language", "fr")
+	w := httptest.NewRecorder()
+	Engine{Localizer: l, Writer: JSONWriter{}}.WithRequest(r).NotFound(w)
+	if got, want := strings.TrimSpace(w.Body.String()), `{"error":"Introuvable"}`; got != want {
+		t.Errorf(errorString, got, want)
+	}
+}
+
+func TestLocalizedTemplateCache(t *testing.T) {
+	fsys := fstest.MapFS{"home.html": {Data: []byte(`{{T "Hello, %s" .}}`)}}
+	l := &Localizer{Locales: []string{"en", "fr"}, Catalog: catalog}
+	rw := NewReloadingTemplateWriter(fsys, func(fsys fs.FS) (map[string]*template.Template, error) {
+		return TemplateMap(fsys, "*.html", "", FuncMap(nil))
+	})
+	rw.Localizer = l
+	for _, language := range []string{"en", "fr", "en", "fr"} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Language", language)
+		w := httptest.NewRecorder()
+		if err := rw.Reply(w, http.StatusOK, Options{TemplateKey: "home.html", Data: "Sherlock", Request: r}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := len(l.clones), 2; got != want {
+		t.Errorf(errorString, got, want)
+	}
+}
+
+func TestLocalizerAfterValidate(t *testing.T) {
+	tmpl := template.Must(template.New("home.html").Funcs(FuncMap(nil)).Parse(`{{T "Hello, %s" .}}`))
+	tw := NewTemplateWriter(map[string]*template.Template{"home.html": tmpl})
+	if err := tw.Validate(TemplateSample{Key: "home.html", Data: "Sherlock"}); err != nil {
+		t.Fatal(err)
+	}
+	tw.Localizer = &Localizer{Locales: []string{"en", "fr"}, Catalog: catalog}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Language", "fr")
+	got, err := tw.RenderString(Options{TemplateKey: "home.html", Data: "Sherlock", Request: r})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Bonjour, Sherlock"; got != want {
+		t.Errorf(errorString, got, want)
+	}
+}
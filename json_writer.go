@@ -1,26 +1,66 @@
 package reply
 
 import (
-	"bytes"
 	"encoding/json"
 	"net/http"
 )
 
 // JSONWriter implements Writer for JSON responses.
-type JSONWriter struct{}
+type JSONWriter struct {
+	// StrictFields defines whether Options.Fields selecting an unknown field
+	// is an error. If false, unknown fields are ignored.
+	StrictFields bool
+
+	Body
+}
+
+// pagedJSON is the document encoded for replies with pagination meta.
+type pagedJSON struct {
+	Data any      `json:"data"`
+	Meta pageMeta `json:"meta"`
+}
+
+// pageMeta holds the meta of a paged JSON document.
+type pageMeta struct {
+	Pagination *Pagination `json:"pagination"`
+}
 
 // Reply sends an HTTP status response header with the given status code
-// and writes encoded JSON to w using the opts provided. If an error occurs
-// at encoding, the function exits and does not write to w.
+// and writes encoded JSON to w using the opts provided. If opts has Fields,
+// only the selected fields of its Data are encoded. If an error occurs at
+// encoding, the function exits and does not write to w.
 func (jw JSONWriter) Reply(w http.ResponseWriter, code int, opts Options) error {
-	w.Header().Set("Content-Type", "application/json")
+	data := opts.Data
+	if opts.Fields != "" {
+		selected, err := selectFields(opts.Data, opts.Fields, jw.StrictFields)
+		if err != nil {
+			return err
+		}
+		data = selected
+	}
+	if opts.Pagination != nil {
+		data = pagedJSON{Data: data, Meta: pageMeta{Pagination: opts.Pagination}}
+	}
+	return jw.encode(w, code, "application/json", data, opts)
+}
+
+// encode sends an HTTP status response header with the given status code and
+// content type and writes v encoded as JSON to w, using opts for conditional
+// and HEAD requests. If an error occurs at encoding, the function exits and
+// does not write to w. Writers built on JSONWriter share this encoding path.
+func (jw JSONWriter) encode(w http.ResponseWriter, code int, contentType string, v any, opts Options) error {
+	w.Header().Set("Content-Type", contentType)
 	w.Header().Set("X-Content-Type-Options", "nosniff")
-	buf := new(bytes.Buffer)
-	if err := json.NewEncoder(buf).Encode(opts.Data); err != nil {
+	if skipBody(opts) {
+		jw.sendHeaders(w, code, opts)
+		return nil
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
 		return err
 	}
-	w.WriteHeader(code)
-	_, _ = buf.WriteTo(w)
+	jw.send(w, code, buf, opts)
 	return nil
 }
 
@@ -34,6 +34,22 @@ func TestJSONReply(t *testing.T) {
 			wantCode: http.StatusCreated,
 			wantBody: `{"baz":"qux","foo":"bar"}`,
 		},
+		"ok; fields": {
+			code:     http.StatusOK,
+			opts:     Options{Data: map[string]string{"baz": "qux", "foo": "bar"}, Fields: "foo"},
+			wantCode: http.StatusOK,
+			wantBody: `{"foo":"bar"}`,
+		},
+		"ok; fields with pagination": {
+			code: http.StatusOK,
+			opts: Options{
+				Data:       []map[string]string{{"baz": "qux", "foo": "bar"}},
+				Fields:     "baz",
+				Pagination: &Pagination{Limit: 1},
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"data":[{"baz":"qux"}],"meta":{"pagination":{"offset":0,"limit":1}}}`,
+		},
 	}
 	jw := JSONWriter{}
 	for name, c := range cases {
@@ -81,3 +97,21 @@ func TestJSONError(t *testing.T) {
 		})
 	}
 }
+
+func BenchmarkJSONReply(b *testing.B) {
+	type user struct {
+		ID    int    `json:"id"`
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	users := make([]user, 100)
+	for i := range users {
+		users[i] = user{ID: i, Name: "Sherlock", Email: "sherlock@221b.uk"}
+	}
+	jw := JSONWriter{}
+	w := discardWriter{header: http.Header{}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = jw.Reply(w, http.StatusOK, Options{Data: users})
+	}
+}
@@ -0,0 +1,260 @@
+package reply
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Resource is implemented by data rendered as a JSON:API resource object.
+// Structs may instead annotate a field with a `jsonapi:"primary,<type>"` tag
+// to define their type and id.
+type Resource interface {
+	ResourceType() string
+	ResourceID() string
+}
+
+// ResourceRelationships is optionally implemented by data rendered as a
+// JSON:API resource object to define its relationships. Values are a related
+// resource, a slice of related resources or nil. Struct fields tagged with
+// `jsonapi:"relation,<name>"` define relationships too.
+type ResourceRelationships interface {
+	ResourceRelationships() map[string]any
+}
+
+// JSONAPIWriter implements Writer for JSON:API (jsonapi.org) documents.
+//
+// A JSONAPIWriter renders Options.Data, a resource or a slice of resources, as
+// the document's primary data. Attributes are the JSON encoding of a resource
+// without its primary and relation fields. Related resources are rendered as
+// resource identifiers and, once each, in the document's included resources.
+type JSONAPIWriter struct {
+	JSONWriter
+}
+
+// jsonapiDocument represents a JSON:API top-level document with data.
+type jsonapiDocument struct {
+	Data     any               `json:"data"`
+	Included []jsonapiResource `json:"included,omitempty"`
+	Links    map[string]string `json:"links,omitempty"`
+	Meta     map[string]any    `json:"meta,omitempty"`
+}
+
+// jsonapiIdentifier represents a JSON:API resource identifier object.
+type jsonapiIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// jsonapiResource represents a JSON:API resource object.
+type jsonapiResource struct {
+	jsonapiIdentifier
+	Attributes    map[string]json.RawMessage     `json:"attributes,omitempty"`
+	Relationships map[string]jsonapiRelationship `json:"relationships,omitempty"`
+}
+
+// jsonapiRelationship represents a JSON:API relationship object. Its Data is
+// a jsonapiIdentifier, a slice of them or nil.
+type jsonapiRelationship struct {
+	Data any `json:"data"`
+}
+
+// jsonapiErrors represents a JSON:API top-level document with errors.
+type jsonapiErrors struct {
+	Errors []jsonapiError `json:"errors"`
+}
+
+// jsonapiError represents a JSON:API error object.
+type jsonapiError struct {
+	Status string `json:"status"`
+	Title  string `json:"title"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Reply sends an HTTP status response header with the given status code and
+// writes a JSON:API document to w with opts.Data as its primary data. If opts
+// has Pagination, its links and total are added to the document. If an error
+// occurs at encoding, the function exits and does not write to w.
+func (aw JSONAPIWriter) Reply(w http.ResponseWriter, code int, opts Options) error {
+	doc := jsonapiDocument{}
+	included := &jsonapiIncluded{
+		seen:    map[jsonapiIdentifier]bool{},
+		primary: map[jsonapiIdentifier]bool{},
+	}
+	if opts.Data != nil {
+		rv := reflect.ValueOf(opts.Data)
+		if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			data := []jsonapiResource{}
+			for i := 0; i < rv.Len(); i++ {
+				res, err := included.resource(rv.Index(i).Interface(), true)
+				if err != nil {
+					return err
+				}
+				data = append(data, res)
+			}
+			doc.Data = data
+		} else {
+			res, err := included.resource(opts.Data, true)
+			if err != nil {
+				return err
+			}
+			doc.Data = res
+		}
+		for _, res := range included.resources {
+			if !included.primary[res.jsonapiIdentifier] {
+				doc.Included = append(doc.Included, res)
+			}
+		}
+	}
+	if pg := opts.Pagination; pg != nil {
+		doc.Links = map[string]string{}
+		for rel, href := range map[string]string{"first": pg.First, "prev": pg.Prev, "next": pg.Next, "last": pg.Last} {
+			if href != "" {
+				doc.Links[rel] = href
+			}
+		}
+		if pg.Total != nil {
+			doc.Meta = map[string]any{"total": *pg.Total}
+		}
+	}
+	return aw.encode(w, code, "application/vnd.api+json", doc, opts)
+}
+
+// Error sends an HTTP response header with the given status code and writes
+// a JSON:API document with error to w as its single error object.
+func (aw JSONAPIWriter) Error(w http.ResponseWriter, error string, code int) {
+	e := jsonapiError{Status: strconv.Itoa(code), Title: http.StatusText(code)}
+	if error != e.Title {
+		e.Detail = error
+	}
+	_ = aw.encode(w, code, "application/vnd.api+json", jsonapiErrors{Errors: []jsonapiError{e}}, Options{})
+}
+
+// jsonapiIncluded collects the resources of a document once each.
+type jsonapiIncluded struct {
+	seen      map[jsonapiIdentifier]bool
+	primary   map[jsonapiIdentifier]bool
+	resources []jsonapiResource
+}
+
+// resource returns the resource object for v, collecting the resources related
+// to v. Related resources are collected without their own relationships.
+func (inc *jsonapiIncluded) resource(v any, primary bool) (jsonapiResource, error) {
+	res := jsonapiResource{}
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if r, ok := v.(Resource); ok {
+		res.Type, res.ID = r.ResourceType(), r.ResourceID()
+	}
+	omit := map[string]bool{}
+	related := map[string]any{}
+	if rv.Kind() == reflect.Struct {
+		for i := 0; i < rv.NumField(); i++ {
+			f := rv.Type().Field(i)
+			tag, ok := f.Tag.Lookup("jsonapi")
+			if !ok || !f.IsExported() {
+				continue
+			}
+			kind, arg, _ := strings.Cut(tag, ",")
+			switch kind {
+			case "primary":
+				res.Type, res.ID = arg, fmt.Sprint(rv.Field(i).Interface())
+			case "relation":
+				related[arg] = rv.Field(i).Interface()
+			default:
+				return res, fmt.Errorf("invalid jsonapi tag '%s' on field %s", tag, f.Name)
+			}
+			omit[jsonFieldName(f)] = true
+		}
+	}
+	if res.Type == "" {
+		return res, fmt.Errorf("no jsonapi type for %T", v)
+	}
+	if r, ok := v.(ResourceRelationships); ok {
+		for name, rel := range r.ResourceRelationships() {
+			related[name] = rel
+		}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return res, err
+	}
+	if err := json.Unmarshal(b, &res.Attributes); err != nil {
+		return res, errors.New("jsonapi resource attributes must encode to a JSON object")
+	}
+	for name := range omit {
+		delete(res.Attributes, name)
+	}
+	// JSON:API reserves "id" and "type", which are members of the resource
+	// itself rather than attributes.
+	delete(res.Attributes, "id")
+	delete(res.Attributes, "type")
+	if primary {
+		inc.primary[res.jsonapiIdentifier] = true
+		names := make([]string, 0, len(related))
+		for name := range related {
+			names = append(names, name)
+		}
+		sort.Strings(names) // include related resources in a stable order
+		for _, name := range names {
+			data, err := inc.relationship(related[name])
+			if err != nil {
+				return res, err
+			}
+			if res.Relationships == nil {
+				res.Relationships = map[string]jsonapiRelationship{}
+			}
+			res.Relationships[name] = jsonapiRelationship{Data: data}
+		}
+	}
+	return res, nil
+}
+
+// relationship returns the resource linkage for rel, a related resource, a
+// slice of them or nil, and collects the related resources.
+func (inc *jsonapiIncluded) relationship(rel any) (any, error) {
+	rv := reflect.ValueOf(rel)
+	switch {
+	case !rv.IsValid() || (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface) && rv.IsNil():
+		return nil, nil
+	case rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array:
+		ids := []jsonapiIdentifier{}
+		for i := 0; i < rv.Len(); i++ {
+			id, err := inc.include(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		return ids, nil
+	default:
+		return inc.include(rel)
+	}
+}
+
+// include collects v, a related resource, if not yet seen and returns its
+// resource identifier.
+func (inc *jsonapiIncluded) include(v any) (jsonapiIdentifier, error) {
+	res, err := inc.resource(v, false)
+	if err != nil {
+		return jsonapiIdentifier{}, err
+	}
+	if !inc.seen[res.jsonapiIdentifier] {
+		inc.seen[res.jsonapiIdentifier] = true
+		inc.resources = append(inc.resources, res)
+	}
+	return res.jsonapiIdentifier, nil
+}
+
+// jsonFieldName returns the name of f in its JSON encoding.
+func jsonFieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
@@ -0,0 +1,161 @@
+package reply
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type person struct {
+	ID   int    `json:"id" jsonapi:"primary,people"`
+	Name string `json:"name"`
+}
+
+type article struct {
+	ID       string    `json:"id" jsonapi:"primary,articles"`
+	Title    string    `json:"title"`
+	Author   *person   `json:"author" jsonapi:"relation,author"`
+	Comments []comment `json:"comments" jsonapi:"relation,comments"`
+}
+
+type comment struct {
+	id   string
+	Body string `json:"body"`
+}
+
+func (c comment) ResourceType() string { return "comments" }
+func (c comment) ResourceID() string   { return c.id }
+
+type user struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+func (u user) ResourceType() string { return "users" }
+func (u user) ResourceID() string   { return u.ID }
+
+type label string
+
+func (l label) ResourceType() string { return "labels" }
+func (l label) ResourceID() string   { return string(l) }
+
+type badTag struct {
+	ID string `jsonapi:"key"`
+}
+
+func TestJSONAPIReply(t *testing.T) {
+	people, _ := url.Parse("/people")
+	watson := &person{ID: 9, Name: "Watson"}
+	cases := map[string]struct {
+		opts     Options
+		wantErr  bool
+		wantBody string
+	}{
+		"error - no type": {
+			opts:    Options{Data: struct{ Name string }{Name: "Sherlock"}},
+			wantErr: true,
+		},
+		"error - bad tag": {
+			opts:    Options{Data: badTag{ID: "1"}},
+			wantErr: true,
+		},
+		"error - attributes not an object": {
+			opts:    Options{Data: label("urgent")},
+			wantErr: true,
+		},
+		"null data": {
+			opts:     Options{},
+			wantBody: `{"data":null}`,
+		},
+		"empty collection": {
+			opts:     Options{Data: []article{}},
+			wantBody: `{"data":[]}`,
+		},
+		"resource interface": {
+			opts:     Options{Data: comment{id: "5", Body: "Elementary"}},
+			wantBody: `{"data":{"type":"comments","id":"5","attributes":{"body":"Elementary"}}}`,
+		},
+		"resource interface with id and type fields": {
+			opts:     Options{Data: user{ID: "1", Type: "admin", Name: "Mycroft"}},
+			wantBody: `{"data":{"type":"users","id":"1","attributes":{"name":"Mycroft"}}}`,
+		},
+		"annotated struct with relationships": {
+			opts: Options{Data: article{
+				ID:       "1",
+				Title:    "A Study in Scarlet",
+				Author:   watson,
+				Comments: []comment{{id: "5", Body: "Elementary"}},
+			}},
+			wantBody: `{"data":{"type":"articles","id":"1","attributes":{"title":"A Study in Scarlet"},"relationships":{"author":{"data":{"type":"people","id":"9"}},"comments":{"data":[{"type":"comments","id":"5"}]}}},"included":[{"type":"people","id":"9","attributes":{"name":"Watson"}},{"type":"comments","id":"5","attributes":{"body":"Elementary"}}]}`,
+		},
+		"collection includes related resources once": {
+			opts: Options{Data: []*article{
+				{ID: "1", Title: "A", Author: watson},
+				{ID: "2", Title: "B", Author: watson},
+				{ID: "3", Title: "C"},
+			}},
+			wantBody: `{"data":[{"type":"articles","id":"1","attributes":{"title":"A"},"relationships":{"author":{"data":{"type":"people","id":"9"}},"comments":{"data":[]}}},{"type":"articles","id":"2","attributes":{"title":"B"},"relationships":{"author":{"data":{"type":"people","id":"9"}},"comments":{"data":[]}}},{"type":"articles","id":"3","attributes":{"title":"C"},"relationships":{"author":{"data":null},"comments":{"data":[]}}}],"included":[{"type":"people","id":"9","attributes":{"name":"Watson"}}]}`,
+		},
+		"pagination": {
+			opts: Options{
+				Data:       []person{*watson},
+				Pagination: NewPagination(people, Page{Limit: 1, Total: total(1)}),
+			},
+			wantBody: `{"data":[{"type":"people","id":"9","attributes":{"name":"Watson"}}],"links":{"first":"/people?limit=1\u0026offset=0","last":"/people?limit=1\u0026offset=0"},"meta":{"total":1}}`,
+		},
+	}
+	aw := JSONAPIWriter{}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			err := aw.Reply(w, http.StatusOK, c.opts)
+			if (err != nil) != c.wantErr {
+				t.Errorf(errorString, err, c.wantErr)
+			}
+			if c.wantErr {
+				return
+			}
+			if got, want := w.Header().Get("Content-Type"), "application/vnd.api+json"; got != want {
+				t.Errorf(errorString, got, want)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != c.wantBody {
+				t.Errorf(errorString, got, c.wantBody)
+			}
+		})
+	}
+}
+
+func TestJSONAPIError(t *testing.T) {
+	cases := map[string]struct {
+		error string
+		code  int
+		want  string
+	}{
+		"status text": {
+			error: http.StatusText(http.StatusNotFound),
+			code:  http.StatusNotFound,
+			want:  `{"errors":[{"status":"404","title":"Not Found"}]}`,
+		},
+		"detail": {
+			error: "no such template 'foo'",
+			code:  http.StatusInternalServerError,
+			want:  `{"errors":[{"status":"500","title":"Internal Server Error","detail":"no such template 'foo'"}]}`,
+		},
+	}
+	aw := JSONAPIWriter{}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			aw.Error(w, c.error, c.code)
+			if got := w.Code; got != c.code {
+				t.Errorf(errorString, got, c.code)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != c.want {
+				t.Errorf(errorString, got, c.want)
+			}
+		})
+	}
+}
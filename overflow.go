@@ -0,0 +1,153 @@
+package reply
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+// Overflow defines how a TemplateWriter handles rendered output beyond its
+// buffer threshold.
+type Overflow int
+
+const (
+	// OverflowBuffer keeps buffering output in memory. It is the default.
+	OverflowBuffer Overflow = iota + 1
+
+	// OverflowSpill spills output to a temporary file, which is sent once
+	// the template has executed. Spilled replies keep their error safety
+	// but are sent without compression.
+	OverflowSpill
+
+	// OverflowStream sends the reply header and streams output to the
+	// response as it is rendered. Streamed replies are sent without
+	// compression, Content-Length or ETag. If the template fails after
+	// streaming began, the reply cannot become an error reply; see
+	// TemplateWriter.StreamErrorMarker.
+	OverflowStream
+)
+
+// StreamError is returned when a reply fails after it began streaming, such
+// as by a TemplateWriter whose template fails, or by Engine.Archive. The reply
+// has been sent in part; a TemplateWriter's has had StreamErrorMarker
+// appended to it.
+type StreamError struct {
+	Err error
+}
+
+func (se *StreamError) Error() string {
+	return "streamed template: " + se.Err.Error()
+}
+
+func (se *StreamError) Unwrap() error {
+	return se.Err
+}
+
+// overflowWriter buffers rendered output up to threshold bytes, beyond which
+// it spills to a temporary file or streams to the response, as mode defines.
+type overflowWriter struct {
+	buf       *bytes.Buffer
+	threshold int
+	mode      Overflow
+
+	// file is the temporary file of spilled output.
+	file *os.File
+
+	// stream begins a streamed reply, returning the writer to stream to.
+	stream    func() io.Writer
+	streaming io.Writer
+}
+
+func (ow *overflowWriter) Write(p []byte) (int, error) {
+	switch {
+	case ow.file != nil:
+		return ow.file.Write(p)
+	case ow.streaming != nil:
+		return ow.streaming.Write(p)
+	case ow.threshold <= 0 || ow.mode <= OverflowBuffer || ow.buf.Len()+len(p) <= ow.threshold:
+		return ow.buf.Write(p)
+	case ow.mode == OverflowSpill:
+		f, err := os.CreateTemp("", "reply-*")
+		if err != nil {
+			return 0, err
+		}
+		ow.file = f
+		if _, err := ow.buf.WriteTo(f); err != nil {
+			return 0, err
+		}
+		return f.Write(p)
+	default:
+		ow.streaming = ow.stream()
+		if _, err := ow.buf.WriteTo(ow.streaming); err != nil {
+			return 0, err
+		}
+		return ow.streaming.Write(p)
+	}
+}
+
+// close removes the temporary file of spilled output, if any.
+func (ow *overflowWriter) close() {
+	if ow.file != nil {
+		ow.file.Close()
+		os.Remove(ow.file.Name())
+	}
+}
+
+// sendFile sends an HTTP status response header with the given status code
+// and writes the content of f, spilled output, to w. Like send, it sets the
+// validators, Content-Length and conditional replies, but does not compress.
+func (b Body) sendFile(w http.ResponseWriter, code int, f *os.File, opts Options) error {
+	h := w.Header()
+	if code == http.StatusOK && b.ETag != nil {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		hash := b.ETag.hash()
+		if _, err := io.Copy(hash, f); err != nil {
+			return err
+		}
+		h.Set("ETag", b.ETag.tag(hash.Sum(nil)))
+	}
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if sendNotModified(w, code, opts) {
+		return nil
+	}
+	if code >= http.StatusOK && code != http.StatusNoContent {
+		h.Set("Content-Length", strconv.FormatInt(size, 10))
+	}
+	w.WriteHeader(code)
+	if opts.Request == nil || opts.Request.Method != http.MethodHead {
+		_, _ = io.Copy(w, f)
+	}
+	return nil
+}
+
+// failStream ends a streamed reply whose template failed with err. If marker
+// is empty, the connection is aborted by panicking with http.ErrAbortHandler,
+// which the net/http server recovers from. Otherwise marker is written to w
+// and a *StreamError is returned.
+func failStream(w io.Writer, marker string, err error) error {
+	if marker == "" {
+		panic(http.ErrAbortHandler)
+	}
+	_, _ = io.WriteString(w, marker)
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return &StreamError{Err: err}
+}
+
+// isStreamError reports whether err is a *StreamError.
+func isStreamError(err error) bool {
+	var se *StreamError
+	return errors.As(err, &se)
+}
@@ -0,0 +1,170 @@
+package reply
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+type report struct {
+	Text string
+	Fail bool
+}
+
+func (r report) Footer() (string, error) {
+	if r.Fail {
+		return "", errors.New("footer unavailable")
+	}
+	return "END", nil
+}
+
+func TestOverflow(t *testing.T) {
+	page := template.Must(template.New("page").Parse(`{{.Text}}{{.Footer}}`))
+	long := strings.Repeat("x", 100)
+	cases := map[string]struct {
+		writer     TemplateWriter
+		opts       Options
+		wantErr    bool
+		wantCode   int
+		wantLength string
+		wantBody   string
+	}{
+		"under threshold": {
+			writer:     TemplateWriter{BufferThreshold: 200, Overflow: OverflowStream},
+			opts:       Options{Data: report{Text: long}},
+			wantCode:   http.StatusOK,
+			wantLength: "103",
+			wantBody:   long + "END",
+		},
+		"buffer": {
+			writer:     TemplateWriter{BufferThreshold: 10},
+			opts:       Options{Data: report{Text: long}},
+			wantCode:   http.StatusOK,
+			wantLength: "103",
+			wantBody:   long + "END",
+		},
+		"spill": {
+			writer:     TemplateWriter{BufferThreshold: 10, Overflow: OverflowSpill, Body: Body{ETag: &ETag{}}},
+			opts:       Options{Data: report{Text: long}},
+			wantCode:   http.StatusOK,
+			wantLength: "103",
+			wantBody:   long + "END",
+		},
+		"spill; error": {
+			writer:   TemplateWriter{BufferThreshold: 10, Overflow: OverflowSpill},
+			opts:     Options{Data: report{Text: long, Fail: true}},
+			wantErr:  true,
+			wantCode: http.StatusOK,
+		},
+		"stream": {
+			writer:   TemplateWriter{BufferThreshold: 10, Overflow: OverflowStream},
+			opts:     Options{Data: report{Text: long}},
+			wantCode: http.StatusCreated,
+			wantBody: long + "END",
+		},
+		"stream; error marker": {
+			writer:   TemplateWriter{BufferThreshold: 10, Overflow: OverflowStream, StreamErrorMarker: "<!-- error -->"},
+			opts:     Options{Data: report{Text: long, Fail: true}},
+			wantErr:  true,
+			wantCode: http.StatusCreated,
+			wantBody: long + "<!-- error -->",
+		},
+		"options override": {
+			writer:   TemplateWriter{BufferThreshold: 1000, Overflow: OverflowSpill},
+			opts:     Options{Data: report{Text: long}, BufferThreshold: 10, Overflow: OverflowStream},
+			wantCode: http.StatusCreated,
+			wantBody: long + "END",
+		},
+		"options force buffer": {
+			writer:     TemplateWriter{BufferThreshold: 10, Overflow: OverflowStream},
+			opts:       Options{Data: report{Text: long}, Overflow: OverflowBuffer},
+			wantCode:   http.StatusCreated,
+			wantLength: "103",
+			wantBody:   long + "END",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			tmp := t.TempDir()
+			t.Setenv("TMPDIR", tmp)
+			c.writer.Templates = map[string]*template.Template{"page": page}
+			c.opts.TemplateKey = "page"
+			code := http.StatusOK
+			if c.wantCode == http.StatusCreated {
+				code = http.StatusCreated
+			}
+			w := httptest.NewRecorder()
+			err := c.writer.Reply(w, code, c.opts)
+			if (err != nil) != c.wantErr {
+				t.Errorf(errorString, err, c.wantErr)
+			}
+			if got := w.Code; got != c.wantCode {
+				t.Errorf(errorString, got, c.wantCode)
+			}
+			if got := w.Header().Get("Content-Length"); got != c.wantLength {
+				t.Errorf(errorString, got, c.wantLength)
+			}
+			if got := w.Body.String(); got != c.wantBody {
+				t.Errorf(errorString, got, c.wantBody)
+			}
+			if entries, _ := os.ReadDir(tmp); len(entries) != 0 {
+				t.Errorf("temporary files not removed: %v", entries)
+			}
+		})
+	}
+}
+
+func TestOverflowEngine(t *testing.T) {
+	page := template.Must(template.New("page").Parse(`{{.Text}}{{.Footer}}`))
+	long := strings.Repeat("x", 100)
+	cases := map[string]struct {
+		overflow Overflow
+		wantCode int
+		wantBody string
+	}{
+		"spill error replies with error": {
+			overflow: OverflowSpill,
+			wantCode: http.StatusInternalServerError,
+			wantBody: errorTemplateBody(http.StatusInternalServerError),
+		},
+		"stream error is not replied to": {
+			overflow: OverflowStream,
+			wantCode: http.StatusOK,
+			wantBody: long + "!",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			tw := NewTemplateWriter(map[string]*template.Template{"page": page})
+			tw.BufferThreshold, tw.Overflow, tw.StreamErrorMarker = 10, c.overflow, "!"
+			w := httptest.NewRecorder()
+			Engine{Writer: tw}.OK(w, Options{TemplateKey: "page", Data: report{Text: long, Fail: true}})
+			if got := w.Code; got != c.wantCode {
+				t.Errorf(errorString, got, c.wantCode)
+			}
+			if got := w.Body.String(); got != c.wantBody {
+				t.Errorf(errorString, got, c.wantBody)
+			}
+		})
+	}
+}
+
+func TestOverflowStreamAbort(t *testing.T) {
+	page := template.Must(template.New("page").Parse(`{{.Text}}{{.Footer}}`))
+	tw := &TemplateWriter{
+		Templates:       map[string]*template.Template{"page": page},
+		BufferThreshold: 10,
+		Overflow:        OverflowStream,
+	}
+	defer func() {
+		if got := recover(); got != http.ErrAbortHandler {
+			t.Errorf(errorString, got, http.ErrAbortHandler)
+		}
+	}()
+	w := httptest.NewRecorder()
+	_ = tw.Reply(w, http.StatusOK, Options{TemplateKey: "page", Data: report{Text: strings.Repeat("x", 100), Fail: true}})
+}
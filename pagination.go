@@ -0,0 +1,152 @@
+package reply
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Query parameters used to build pagination links.
+const (
+	offsetParam = "offset"
+	limitParam  = "limit"
+	cursorParam = "cursor"
+)
+
+// Page describes a page of a collection. Offset based pages set Offset and
+// Limit; cursor based pages set Cursor and, when known, NextCursor and
+// PrevCursor.
+type Page struct {
+	// Offset is the index of the first item in the page.
+	Offset int
+
+	// Limit is the maximum number of items in a page.
+	Limit int
+
+	// Total is the number of items in the entire collection. A nil Total
+	// means the total is unknown.
+	Total *int
+
+	// HasMore defines whether items follow the page when Total is unknown,
+	// such as when a query fetching Limit+1 items returns more than Limit.
+	HasMore bool
+
+	// Cursor is an opaque token identifying the current page.
+	Cursor string
+
+	// NextCursor and PrevCursor are opaque tokens identifying the next and
+	// previous pages. Empty values mean there is no such page.
+	NextCursor string
+	PrevCursor string
+}
+
+// Pagination represents the pagination meta of a reply. It is exposed to
+// templates through PageData and encoded as meta by a JSONWriter.
+type Pagination struct {
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+	Total  *int   `json:"total,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
+	First  string `json:"first,omitempty"`
+	Prev   string `json:"prev,omitempty"`
+	Next   string `json:"next,omitempty"`
+	Last   string `json:"last,omitempty"`
+}
+
+// PageData is the data passed to a template when Options has Pagination.
+type PageData struct {
+	Data       any
+	Pagination *Pagination
+}
+
+// NewPagination returns the Pagination for p with links relative to u.
+// Cursor based pages link to first, prev and next. Offset based pages link to
+// first and prev, and to next and last when the total is known; if the total
+// is unknown, next is linked only if p.HasMore and last is omitted.
+func NewPagination(u *url.URL, p Page) *Pagination {
+	pg := &Pagination{Offset: p.Offset, Limit: p.Limit, Cursor: p.Cursor}
+	if p.Total != nil {
+		total := *p.Total
+		pg.Total = &total
+	}
+	if p.Cursor != "" || p.NextCursor != "" || p.PrevCursor != "" {
+		pg.First = pageURL(u, map[string]string{cursorParam: ""})
+		if p.PrevCursor != "" {
+			pg.Prev = pageURL(u, map[string]string{cursorParam: p.PrevCursor})
+		}
+		if p.NextCursor != "" {
+			pg.Next = pageURL(u, map[string]string{cursorParam: p.NextCursor})
+		}
+		return pg
+	}
+	if p.Limit <= 0 {
+		return pg
+	}
+	limit := strconv.Itoa(p.Limit)
+	link := func(offset int) string {
+		return pageURL(u, map[string]string{offsetParam: strconv.Itoa(offset), limitParam: limit})
+	}
+	pg.First = link(0)
+	if p.Offset > 0 {
+		pg.Prev = link(max(p.Offset-p.Limit, 0))
+	}
+	if p.Total == nil {
+		if p.HasMore {
+			pg.Next = link(p.Offset + p.Limit)
+		}
+		return pg
+	}
+	total := *p.Total
+	if p.Offset+p.Limit < total {
+		pg.Next = link(p.Offset + p.Limit)
+	}
+	pg.Last = link(max((total-1)/p.Limit*p.Limit, 0))
+	return pg
+}
+
+// Link returns the RFC 8288 Link header value for pg.
+func (pg *Pagination) Link() string {
+	links := []string{}
+	for _, l := range []struct{ rel, href string }{
+		{"first", pg.First},
+		{"prev", pg.Prev},
+		{"next", pg.Next},
+		{"last", pg.Last},
+	} {
+		if l.href != "" {
+			links = append(links, "<"+l.href+`>; rel="`+l.rel+`"`)
+		}
+	}
+	return strings.Join(links, ", ")
+}
+
+// pageURL returns u with its query parameters set to params. Empty values
+// remove the parameter.
+func pageURL(u *url.URL, params map[string]string) string {
+	next := *u
+	query := next.Query()
+	for k, v := range params {
+		if v == "" {
+			query.Del(k)
+		} else {
+			query.Set(k, v)
+		}
+	}
+	next.RawQuery = query.Encode()
+	return next.String()
+}
+
+// Paginate replies with HTTP Status 200 OK for a page of a collection. It sets
+// the Link and X-Total-Count headers for p using u, the request URL, and sets
+// the Pagination in opts for use by the Writer.
+func (e Engine) Paginate(w http.ResponseWriter, u *url.URL, p Page, opts Options) {
+	opts.Pagination = NewPagination(u, p)
+	if link := opts.Pagination.Link(); link != "" {
+		w.Header().Set("Link", link)
+	}
+	if opts.Pagination.Total != nil {
+		w.Header().Set("X-Total-Count", strconv.Itoa(*opts.Pagination.Total))
+	}
+	e.OK(w, opts)
+}
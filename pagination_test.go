@@ -0,0 +1,117 @@
+package reply
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func total(n int) *int {
+	return &n
+}
+
+func TestNewPagination(t *testing.T) {
+	u, _ := url.Parse("/items?sort=name")
+	cases := map[string]struct {
+		page     Page
+		wantLink string
+	}{
+		"offset; first page": {
+			page:     Page{Offset: 0, Limit: 10, Total: total(25)},
+			wantLink: `</items?limit=10&offset=0&sort=name>; rel="first", </items?limit=10&offset=10&sort=name>; rel="next", </items?limit=10&offset=20&sort=name>; rel="last"`,
+		},
+		"offset; middle page": {
+			page:     Page{Offset: 10, Limit: 10, Total: total(25)},
+			wantLink: `</items?limit=10&offset=0&sort=name>; rel="first", </items?limit=10&offset=0&sort=name>; rel="prev", </items?limit=10&offset=20&sort=name>; rel="next", </items?limit=10&offset=20&sort=name>; rel="last"`,
+		},
+		"offset; last page": {
+			page:     Page{Offset: 20, Limit: 10, Total: total(25)},
+			wantLink: `</items?limit=10&offset=0&sort=name>; rel="first", </items?limit=10&offset=10&sort=name>; rel="prev", </items?limit=10&offset=20&sort=name>; rel="last"`,
+		},
+		"offset; empty collection": {
+			page:     Page{Limit: 10, Total: total(0)},
+			wantLink: `</items?limit=10&offset=0&sort=name>; rel="first", </items?limit=10&offset=0&sort=name>; rel="last"`,
+		},
+		"offset; unknown total": {
+			page:     Page{Offset: 5, Limit: 10, HasMore: true},
+			wantLink: `</items?limit=10&offset=0&sort=name>; rel="first", </items?limit=10&offset=0&sort=name>; rel="prev", </items?limit=10&offset=15&sort=name>; rel="next"`,
+		},
+		"offset; unknown total, last page": {
+			page:     Page{Offset: 5, Limit: 10},
+			wantLink: `</items?limit=10&offset=0&sort=name>; rel="first", </items?limit=10&offset=0&sort=name>; rel="prev"`,
+		},
+		"offset; no limit": {
+			page:     Page{Total: total(3)},
+			wantLink: "",
+		},
+		"cursor": {
+			page:     Page{Cursor: "b", PrevCursor: "a", NextCursor: "c"},
+			wantLink: `</items?sort=name>; rel="first", </items?cursor=a&sort=name>; rel="prev", </items?cursor=c&sort=name>; rel="next"`,
+		},
+		"cursor; last page": {
+			page:     Page{Cursor: "c", PrevCursor: "b"},
+			wantLink: `</items?sort=name>; rel="first", </items?cursor=b&sort=name>; rel="prev"`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := NewPagination(u, c.page).Link(); got != c.wantLink {
+				t.Errorf(errorString, got, c.wantLink)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	u, _ := url.Parse("/items")
+	list := template.Must(template.New("list").Parse(`{{range .Data}}{{.}} {{end}}{{.Pagination.Next}}`))
+	cases := map[string]struct {
+		reply     Engine
+		page      Page
+		wantTotal string
+		wantBody  string
+	}{
+		"jw": {
+			reply:     Engine{Writer: JSONWriter{}},
+			page:      Page{Limit: 2, Total: total(3)},
+			wantTotal: "3",
+			wantBody:  `{"data":["a","b"],"meta":{"pagination":{"offset":0,"limit":2,"total":3,"first":"/items?limit=2\u0026offset=0","next":"/items?limit=2\u0026offset=2","last":"/items?limit=2\u0026offset=2"}}}`,
+		},
+		"jw; unknown total": {
+			reply:     Engine{Writer: JSONWriter{}},
+			page:      Page{Cursor: "x"},
+			wantTotal: "",
+			wantBody:  `{"data":["a","b"],"meta":{"pagination":{"offset":0,"limit":0,"cursor":"x","first":"/items"}}}`,
+		},
+		"jw; zero total": {
+			reply:     Engine{Writer: JSONWriter{}},
+			page:      Page{Limit: 2, Total: total(0)},
+			wantTotal: "0",
+			wantBody:  `{"data":["a","b"],"meta":{"pagination":{"offset":0,"limit":2,"total":0,"first":"/items?limit=2\u0026offset=0","last":"/items?limit=2\u0026offset=0"}}}`,
+		},
+		"tw": {
+			reply:     Engine{Writer: NewTemplateWriter(map[string]*template.Template{"list": list})},
+			page:      Page{Limit: 2, Total: total(3)},
+			wantTotal: "3",
+			wantBody:  "a b /items?limit=2&amp;offset=2",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c.reply.Paginate(w, u, c.page, Options{TemplateKey: "list", Data: []string{"a", "b"}})
+			if got := w.Code; got != http.StatusOK {
+				t.Errorf(errorString, got, http.StatusOK)
+			}
+			if got := w.Header().Get("X-Total-Count"); got != c.wantTotal {
+				t.Errorf(errorString, got, c.wantTotal)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != c.wantBody {
+				t.Errorf(errorString, got, c.wantBody)
+			}
+		})
+	}
+}
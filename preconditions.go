@@ -0,0 +1,41 @@
+package reply
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CheckPreconditions evaluates the If-Match and If-Unmodified-Since headers
+// of r, typically a PUT or PATCH, against the current etag and modtime of its
+// target resource, and reports whether the handler should proceed. An etag
+// without quotes, such as a version number, is quoted; an empty etag means the
+// resource has no current representation and a zero modtime is unknown.
+//
+// If r has neither header, or an invalid If-Unmodified-Since, it replies with
+// HTTP Status 428 Precondition Required. If a precondition is not met, it
+// replies with HTTP Status 412 Precondition Failed. If-Match takes precedence
+// over If-Unmodified-Since, and uses strong comparison.
+func (e Engine) CheckPreconditions(w http.ResponseWriter, r *http.Request, etag string, modtime time.Time) bool {
+	if etag != "" && !strings.HasSuffix(etag, `"`) {
+		etag = `"` + etag + `"`
+	}
+	im := r.Header.Get("If-Match")
+	ius, err := http.ParseTime(r.Header.Get("If-Unmodified-Since"))
+	switch {
+	case im != "":
+		if etag == "" || !etagMatch(im, etag, false) {
+			e.Error(w, "precondition failed: the resource has been modified since it was retrieved", http.StatusPreconditionFailed)
+			return false
+		}
+	case err == nil:
+		if modtime.IsZero() || modtime.Truncate(time.Second).After(ius) {
+			e.Error(w, "precondition failed: the resource has been modified since it was retrieved", http.StatusPreconditionFailed)
+			return false
+		}
+	default:
+		e.Error(w, "precondition required: retry the request with an If-Match header", http.StatusPreconditionRequired)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package reply
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckPreconditions(t *testing.T) {
+	modtime := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	ejw := Engine{Writer: JSONWriter{}}
+	cases := map[string]struct {
+		header   map[string]string
+		etag     string
+		modtime  time.Time
+		want     bool
+		wantCode int
+		wantBody string
+	}{
+		"no preconditions": {
+			etag:     `"v1"`,
+			wantCode: http.StatusPreconditionRequired,
+			wantBody: `{"error":"precondition required: retry the request with an If-Match header"}`,
+		},
+		"invalid if-unmodified-since": {
+			header:   map[string]string{"If-Unmodified-Since": "yesterday"},
+			modtime:  modtime,
+			wantCode: http.StatusPreconditionRequired,
+			wantBody: `{"error":"precondition required: retry the request with an If-Match header"}`,
+		},
+		"if-match matches": {
+			header: map[string]string{"If-Match": `"v0", "v1"`},
+			etag:   `"v1"`,
+			want:   true,
+		},
+		"if-match matches version": {
+			header: map[string]string{"If-Match": `"7"`},
+			etag:   "7",
+			want:   true,
+		},
+		"if-match star": {
+			header: map[string]string{"If-Match": "*"},
+			etag:   `"v1"`,
+			want:   true,
+		},
+		"if-match star; no representation": {
+			header:   map[string]string{"If-Match": "*"},
+			wantCode: http.StatusPreconditionFailed,
+			wantBody: `{"error":"precondition failed: the resource has been modified since it was retrieved"}`,
+		},
+		"if-match differs": {
+			header:   map[string]string{"If-Match": `"v0"`},
+			etag:     `"v1"`,
+			wantCode: http.StatusPreconditionFailed,
+			wantBody: `{"error":"precondition failed: the resource has been modified since it was retrieved"}`,
+		},
+		"if-match weak never matches": {
+			header:   map[string]string{"If-Match": `W/"v1"`},
+			etag:     `W/"v1"`,
+			wantCode: http.StatusPreconditionFailed,
+			wantBody: `{"error":"precondition failed: the resource has been modified since it was retrieved"}`,
+		},
+		"if-match takes precedence": {
+			header:  map[string]string{"If-Match": `"v1"`, "If-Unmodified-Since": modtime.Add(-time.Hour).Format(http.TimeFormat)},
+			etag:    `"v1"`,
+			modtime: modtime,
+			want:    true,
+		},
+		"if-unmodified-since; unmodified": {
+			header:  map[string]string{"If-Unmodified-Since": modtime.Format(http.TimeFormat)},
+			modtime: modtime.Add(time.Millisecond),
+			want:    true,
+		},
+		"if-unmodified-since; modified": {
+			header:   map[string]string{"If-Unmodified-Since": modtime.Add(-time.Hour).Format(http.TimeFormat)},
+			modtime:  modtime,
+			wantCode: http.StatusPreconditionFailed,
+			wantBody: `{"error":"precondition failed: the resource has been modified since it was retrieved"}`,
+		},
+		"if-unmodified-since; unknown modtime": {
+			header:   map[string]string{"If-Unmodified-Since": modtime.Format(http.TimeFormat)},
+			wantCode: http.StatusPreconditionFailed,
+			wantBody: `{"error":"precondition failed: the resource has been modified since it was retrieved"}`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/", nil)
+			for k, v := range c.header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			if got := ejw.CheckPreconditions(w, r, c.etag, c.modtime); got != c.want {
+				t.Errorf(errorString, got, c.want)
+			}
+			if c.want {
+				if w.Body.Len() != 0 {
+					t.Errorf("unwanted reply %q", w.Body.String())
+				}
+				return
+			}
+			if got := w.Code; got != c.wantCode {
+				t.Errorf(errorString, got, c.wantCode)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != c.wantBody {
+				t.Errorf(errorString, got, c.wantBody)
+			}
+		})
+	}
+}
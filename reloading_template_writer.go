@@ -0,0 +1,173 @@
+package reply
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ReloadingTemplateWriter implements Writer for template responses like a
+// TemplateWriter, reloading its templates from FS as they change so that
+// edits are served without a restart. It is intended for development. A
+// ReloadingTemplateWriter must not be copied after first use.
+type ReloadingTemplateWriter struct {
+	// TemplateWriter defines how replies are written. Its Templates are
+	// ignored in favor of those loaded from FS.
+	TemplateWriter
+
+	// FS defines the file system templates are loaded from.
+	FS fs.FS
+
+	// Load defines how templates are loaded from FS, such as by TemplateMap.
+	// Default "error.html" and "no_content.html" templates are added as by
+	// NewTemplateWriter.
+	Load func(fsys fs.FS) (map[string]*template.Template, error)
+
+	// Interval defines how often FS is polled for modified files. Templates
+	// are reloaded once a poll finds a file added, removed or modified. If
+	// zero, templates are reloaded on every reply.
+	Interval time.Duration
+
+	// ShowParseErrors defines whether replies fail with the error of the
+	// latest reload while it fails, so that an Engine with Debug shows it on
+	// its error page. Otherwise, the last good templates are used, and the
+	// error is available from Err. Error replies always use the last good
+	// templates.
+	ShowParseErrors bool
+
+	mu        sync.Mutex
+	templates map[string]*template.Template
+	err       error
+	stamp     uint64
+	checked   time.Time
+}
+
+// NewReloadingTemplateWriter returns a new ReloadingTemplateWriter loading
+// templates from fsys with load, and showing parse errors.
+func NewReloadingTemplateWriter(fsys fs.FS, load func(fsys fs.FS) (map[string]*template.Template, error)) *ReloadingTemplateWriter {
+	return &ReloadingTemplateWriter{FS: fsys, Load: load, ShowParseErrors: true}
+}
+
+// Reply reloads rw's templates if needed and replies like TemplateWriter's
+// Reply.
+func (rw *ReloadingTemplateWriter) Reply(w http.ResponseWriter, code int, opts Options) error {
+	tw, err := rw.writer()
+	if err != nil && rw.ShowParseErrors {
+		return err
+	}
+	return tw.Reply(w, code, opts)
+}
+
+// Error reloads rw's templates if needed and replies like TemplateWriter's
+// Error.
+func (rw *ReloadingTemplateWriter) Error(w http.ResponseWriter, error string, code int) {
+	rw.ErrorDetails(w, nil, error, code, nil)
+}
+
+// ErrorDetails reloads rw's templates if needed and replies like
+// TemplateWriter's ErrorDetails.
+func (rw *ReloadingTemplateWriter) ErrorDetails(w http.ResponseWriter, r *http.Request, error string, code int, details any) {
+	tw, _ := rw.writer()
+	tw.ErrorDetails(w, r, error, code, details)
+}
+
+// Render reloads rw's templates if needed and renders like TemplateWriter's
+// Render.
+func (rw *ReloadingTemplateWriter) Render(w io.Writer, opts Options) error {
+	tw, err := rw.writer()
+	if err != nil && rw.ShowParseErrors {
+		return err
+	}
+	return tw.Render(w, opts)
+}
+
+// RenderString reloads rw's templates if needed and renders like
+// TemplateWriter's RenderString.
+func (rw *ReloadingTemplateWriter) RenderString(opts Options) (string, error) {
+	tw, err := rw.writer()
+	if err != nil && rw.ShowParseErrors {
+		return "", err
+	}
+	return tw.RenderString(opts)
+}
+
+// Validate reloads rw's templates if needed and validates them like
+// TemplateWriter's Validate, failing with the error of the reload, if any.
+func (rw *ReloadingTemplateWriter) Validate(samples ...TemplateSample) error {
+	tw, err := rw.writer()
+	if err != nil {
+		return err
+	}
+	return tw.Validate(samples...)
+}
+
+// writer returns a TemplateWriter like rw's with the last good templates,
+// reloaded if needed, and the error of the latest reload.
+func (rw *ReloadingTemplateWriter) writer() (*TemplateWriter, error) {
+	templates, err := rw.reload()
+	tw := rw.TemplateWriter
+	tw.Templates = templates
+	return &tw, err
+}
+
+// Err returns the error of the latest reload, or nil if it succeeded.
+func (rw *ReloadingTemplateWriter) Err() error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	return rw.err
+}
+
+// reload reloads rw's templates if they are due a check and FS has changed,
+// and returns the last good templates and the error of the latest reload.
+func (rw *ReloadingTemplateWriter) reload() (map[string]*template.Template, error) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	now := time.Now()
+	if rw.templates != nil && rw.Interval > 0 && now.Sub(rw.checked) < rw.Interval {
+		return rw.templates, rw.err
+	}
+	rw.checked = now
+	stamp, err := fsStamp(rw.FS)
+	if err == nil && rw.templates != nil && rw.Interval > 0 && stamp == rw.stamp {
+		return rw.templates, rw.err
+	}
+	rw.stamp = stamp
+	var templates map[string]*template.Template
+	if err == nil {
+		templates, err = rw.Load(rw.FS)
+	}
+	if err != nil {
+		rw.err = fmt.Errorf("reload templates: %w", err)
+		if rw.templates == nil {
+			rw.templates = NewTemplateWriter(map[string]*template.Template{}).Templates
+		}
+		return rw.templates, rw.err
+	}
+	rw.templates, rw.err = NewTemplateWriter(templates).Templates, nil
+	return rw.templates, nil
+}
+
+// fsStamp returns a hash of the paths, sizes and modification times of the
+// files in fsys, which changes as files are added, removed or modified.
+func fsStamp(fsys fs.FS) (uint64, error) {
+	h := fnv.New64a()
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		h.Write([]byte(path))
+		_ = binary.Write(h, binary.LittleEndian, [2]int64{info.Size(), info.ModTime().UnixNano()})
+		return nil
+	})
+	return h.Sum64(), err
+}
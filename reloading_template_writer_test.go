@@ -0,0 +1,140 @@
+package reply
+
+import (
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestReloadingTemplateWriter(t *testing.T) {
+	modtime := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	load := func(fsys fs.FS) (map[string]*template.Template, error) {
+		return TemplateMap(fsys, "*.html", "", nil)
+	}
+	type step struct {
+		page     string // new content of page.html, if not empty
+		wantCode int
+		wantBody string
+		wantErr  bool
+	}
+	cases := map[string]struct {
+		interval   time.Duration
+		showErrors bool
+		steps      []step
+	}{
+		"reload every reply": {
+			showErrors: true,
+			steps: []step{
+				{wantCode: http.StatusOK, wantBody: "v1"},
+				{page: "v2", wantCode: http.StatusOK, wantBody: "v2"},
+			},
+		},
+		"parse error shown": {
+			showErrors: true,
+			steps: []step{
+				{wantCode: http.StatusOK, wantBody: "v1"},
+				{page: "{{.Broken", wantCode: http.StatusInternalServerError, wantBody: "<p>reload templates: template: page.html:1: unclosed action</p>", wantErr: true},
+				{page: "v3", wantCode: http.StatusOK, wantBody: "v3"},
+			},
+		},
+		"parse error falls back": {
+			steps: []step{
+				{wantCode: http.StatusOK, wantBody: "v1"},
+				{page: "{{.Broken", wantCode: http.StatusOK, wantBody: "v1", wantErr: true},
+				{page: "v3", wantCode: http.StatusOK, wantBody: "v3"},
+			},
+		},
+		"parse error without good templates": {
+			showErrors: true,
+			steps: []step{
+				{page: "{{.Broken", wantCode: http.StatusInternalServerError, wantBody: "<p>reload templates: template: page.html:1: unclosed action</p>", wantErr: true},
+				{page: "v2", wantCode: http.StatusOK, wantBody: "v2"},
+			},
+		},
+		"polled; not yet due": {
+			interval: time.Hour,
+			steps: []step{
+				{wantCode: http.StatusOK, wantBody: "v1"},
+				{page: "v2", wantCode: http.StatusOK, wantBody: "v1"},
+			},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			fsys := fstest.MapFS{"page.html": {Data: []byte("v1"), ModTime: modtime}}
+			rw := NewReloadingTemplateWriter(fsys, load)
+			rw.Interval, rw.ShowParseErrors = c.interval, c.showErrors
+			e := Engine{Debug: true, Writer: rw}
+			for i, s := range c.steps {
+				if s.page != "" {
+					modtime = modtime.Add(time.Second)
+					fsys["page.html"] = &fstest.MapFile{Data: []byte(s.page), ModTime: modtime}
+				}
+				w := httptest.NewRecorder()
+				e.OK(w, Options{TemplateKey: "page.html"})
+				if got := w.Code; got != s.wantCode {
+					t.Errorf("step %d: "+errorString, i, got, s.wantCode)
+				}
+				if got := strings.TrimSpace(w.Body.String()); got != s.wantBody {
+					t.Errorf("step %d: "+errorString, i, got, s.wantBody)
+				}
+				if got := rw.Err(); (got != nil) != s.wantErr {
+					t.Errorf("step %d: "+errorString, i, got, s.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestReloadingTemplateWriterPoll(t *testing.T) {
+	fsys := fstest.MapFS{"page.html": {Data: []byte("v1")}}
+	rw := NewReloadingTemplateWriter(fsys, func(fsys fs.FS) (map[string]*template.Template, error) {
+		return TemplateMap(fsys, "*.html", "", nil)
+	})
+	rw.Interval = time.Millisecond
+	reply := func() string {
+		w := httptest.NewRecorder()
+		if err := rw.Reply(w, http.StatusOK, Options{TemplateKey: "page.html"}); err != nil {
+			t.Fatal(err)
+		}
+		return w.Body.String()
+	}
+	if got, want := reply(), "v1"; got != want {
+		t.Errorf(errorString, got, want)
+	}
+	fsys["page.html"] = &fstest.MapFile{Data: []byte("v2"), ModTime: time.Now()}
+	time.Sleep(2 * time.Millisecond)
+	if got, want := reply(), "v2"; got != want {
+		t.Errorf(errorString, got, want)
+	}
+}
+
+func TestReloadingTemplateWriterRender(t *testing.T) {
+	fsys := fstest.MapFS{"page.html": {Data: []byte("Hello, {{.}}")}}
+	rw := NewReloadingTemplateWriter(fsys, func(fsys fs.FS) (map[string]*template.Template, error) {
+		return TemplateMap(fsys, "*.html", "", nil)
+	})
+	got, err := rw.RenderString(Options{TemplateKey: "page.html", Data: "Sherlock"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Hello, Sherlock"; got != want {
+		t.Errorf(errorString, got, want)
+	}
+	if err := rw.Validate(TemplateSample{Key: "page.html", Data: "Sherlock"}); err != nil {
+		t.Errorf(errorString, err, nil)
+	}
+	fsys["page.html"] = &fstest.MapFile{Data: []byte("{{.Broken"), ModTime: time.Now()}
+	var b strings.Builder
+	if err := rw.Render(&b, Options{TemplateKey: "page.html"}); err == nil {
+		t.Errorf(errorString, err, "a parse error")
+	}
+	if err := rw.Validate(); err == nil {
+		t.Errorf(errorString, err, "a parse error")
+	}
+}
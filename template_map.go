@@ -0,0 +1,163 @@
+package reply
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template/parse"
+)
+
+// TemplateConfig defines the files parsed by ParseTemplates.
+type TemplateConfig struct {
+	// Pages defines a glob of page files. Each page is parsed into its own
+	// template, keyed by its base name.
+	Pages string
+
+	// Recursive defines whether the directory of Pages is walked recursively
+	// for files whose base name matches that of Pages. Pages are then keyed
+	// by their slash separated path relative to the directory, so that
+	// "admin/index.html" and "public/index.html" are distinct.
+	Recursive bool
+
+	// Shared defines globs of files parsed into every page template, such as
+	// layouts, partials and components. Templates defined by a page override
+	// those of shared files, as with a block.
+	Shared []string
+
+	// Funcs defines functions available to every template.
+	Funcs template.FuncMap
+}
+
+// ParseTemplates returns a map of string to HTML template using fsys as its
+// source, as cfg defines. Shared files are parsed once and cloned into each
+// page. If a template name is defined by more than one shared file, or two
+// pages have the same key, an error reporting both files is returned.
+func ParseTemplates(fsys fs.FS, cfg TemplateConfig) (map[string]*template.Template, error) {
+	shared, err := parseShared(fsys, cfg.Shared, cfg.Funcs)
+	if err != nil {
+		return nil, err
+	}
+	pages, err := pageFiles(fsys, cfg.Pages, cfg.Recursive)
+	if err != nil {
+		return nil, err
+	}
+	templates := map[string]*template.Template{}
+	files := map[string]string{}
+	for _, p := range pages {
+		if other, ok := files[p.key]; ok {
+			return nil, fmt.Errorf("template: key %q is used by both %s and %s", p.key, other, p.file)
+		}
+		files[p.key] = p.file
+		tmpl, err := parsePage(fsys, shared, p.key, p.file)
+		if err != nil {
+			return nil, err
+		}
+		templates[p.key] = tmpl
+	}
+	return templates, nil
+}
+
+// pageFile is a page file and its template key.
+type pageFile struct {
+	key  string
+	file string
+}
+
+// pageFiles returns the page files matching pattern, keyed by base name. If
+// recursive is true, the directory of pattern is walked for files matching
+// its base name, keyed by their path relative to the directory.
+func pageFiles(fsys fs.FS, pattern string, recursive bool) ([]pageFile, error) {
+	if !recursive {
+		files, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return nil, err
+		}
+		pages := make([]pageFile, len(files))
+		for i, file := range files {
+			pages[i] = pageFile{key: filepath.Base(file), file: file}
+		}
+		return pages, nil
+	}
+	dir, match := path.Split(pattern)
+	dir = path.Clean(dir)
+	if _, err := path.Match(match, ""); err != nil {
+		return nil, err
+	}
+	var pages []pageFile
+	err := fs.WalkDir(fsys, dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if ok, _ := path.Match(match, d.Name()); !ok {
+			return nil
+		}
+		key := file
+		if dir != "." {
+			key = strings.TrimPrefix(file, dir+"/")
+		}
+		pages = append(pages, pageFile{key: key, file: file})
+		return nil
+	})
+	return pages, err
+}
+
+// parseShared parses the files matching patterns into a template set. Each
+// file is parsed on its own first so that a template name defined by two
+// files is reported rather than silently redefined.
+func parseShared(fsys fs.FS, patterns []string, funcs template.FuncMap) (*template.Template, error) {
+	shared := template.New("").Funcs(funcs)
+	parsed := map[string]bool{}
+	definedBy := map[string]string{}
+	for _, pattern := range patterns {
+		files, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("template: pattern matches no files: %#q", pattern)
+		}
+		for _, file := range files {
+			if parsed[file] {
+				continue // matched by an earlier pattern
+			}
+			parsed[file] = true
+			b, err := fs.ReadFile(fsys, file)
+			if err != nil {
+				return nil, err
+			}
+			tmpl, err := template.New(filepath.Base(file)).Funcs(funcs).Parse(string(b))
+			if err != nil {
+				return nil, err
+			}
+			for _, t := range tmpl.Templates() {
+				if t.Tree == nil || parse.IsEmptyTree(t.Tree.Root) {
+					continue
+				}
+				if other, ok := definedBy[t.Name()]; ok {
+					return nil, fmt.Errorf("template: %q is defined by both %s and %s", t.Name(), other, file)
+				}
+				definedBy[t.Name()] = file
+				if _, err := shared.AddParseTree(t.Name(), t.Tree); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return shared, nil
+}
+
+// parsePage parses the page file into a clone of shared as the template name.
+func parsePage(fsys fs.FS, shared *template.Template, name, file string) (*template.Template, error) {
+	b, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return nil, err
+	}
+	clone, err := shared.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return clone.New(name).Parse(string(b))
+}
@@ -0,0 +1,139 @@
+package reply
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseTemplates(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html":      {Data: []byte(`{{define "base"}}<title>{{block "title" .}}Site{{end}}</title>{{template "nav"}}{{template "main" .}}{{template "footer"}}{{end}}`)},
+		"partials/nav.html":      {Data: []byte(`{{define "nav"}}<nav>{{upper "menu"}}</nav>{{end}}`)},
+		"partials/footer.html":   {Data: []byte(`{{define "footer"}}<footer></footer>{{end}}`)},
+		"partials/dup/nav.html":  {Data: []byte(`{{define "nav"}}<nav>other</nav>{{end}}`)},
+		"pages/hello.html":       {Data: []byte(`{{define "main"}}Hello, {{.}}{{end}}`)},
+		"pages/about.html":       {Data: []byte(`{{define "title"}}About{{end}}{{define "main"}}About {{.}}{{end}}`)},
+		"broken/pages/bad.html":  {Data: []byte(`{{define "main"}`)},
+		"broken/partials/x.html": {Data: []byte(`{{template "nav"`)},
+		"site/index.html":        {Data: []byte(`{{define "main"}}Home {{.}}{{end}}`)},
+		"site/admin/index.html":  {Data: []byte(`{{define "main"}}Admin {{.}}{{end}}`)},
+		"site/public/index.html": {Data: []byte(`{{define "main"}}Public {{.}}{{end}}`)},
+		"site/public/notes.txt":  {Data: []byte(`not a page`)},
+	}
+	funcs := template.FuncMap{"upper": strings.ToUpper}
+	cases := map[string]struct {
+		cfg      TemplateConfig
+		wantErr  string
+		wantKeys map[string]string // key to output of its "base" template
+	}{
+		"layout and partials": {
+			cfg: TemplateConfig{
+				Pages:  "pages/*.html",
+				Shared: []string{"layouts/*.html", "partials/*.html"},
+				Funcs:  funcs,
+			},
+			wantKeys: map[string]string{
+				"hello.html": "<title>Site</title><nav>MENU</nav>Hello, Sherlock<footer></footer>",
+				"about.html": "<title>About</title><nav>MENU</nav>About Sherlock<footer></footer>",
+			},
+		},
+		"overlapping shared globs": {
+			cfg: TemplateConfig{
+				Pages:  "pages/hello.html",
+				Shared: []string{"layouts/*.html", "partials/*.html", "partials/nav.html"},
+				Funcs:  funcs,
+			},
+			wantKeys: map[string]string{
+				"hello.html": "<title>Site</title><nav>MENU</nav>Hello, Sherlock<footer></footer>",
+			},
+		},
+		"recursive": {
+			cfg: TemplateConfig{
+				Pages:     "site/*.html",
+				Recursive: true,
+				Shared:    []string{"layouts/*.html", "partials/*.html"},
+				Funcs:     funcs,
+			},
+			wantKeys: map[string]string{
+				"index.html":        "<title>Site</title><nav>MENU</nav>Home Sherlock<footer></footer>",
+				"admin/index.html":  "<title>Site</title><nav>MENU</nav>Admin Sherlock<footer></footer>",
+				"public/index.html": "<title>Site</title><nav>MENU</nav>Public Sherlock<footer></footer>",
+			},
+		},
+		"recursive from root": {
+			cfg: TemplateConfig{
+				Pages:     "hello.html",
+				Recursive: true,
+				Shared:    []string{"layouts/*.html", "partials/*.html"},
+				Funcs:     funcs,
+			},
+			wantKeys: map[string]string{
+				"pages/hello.html": "<title>Site</title><nav>MENU</nav>Hello, Sherlock<footer></footer>",
+			},
+		},
+		"recursive pattern error": {
+			cfg:     TemplateConfig{Pages: "site/[", Recursive: true},
+			wantErr: "syntax error in pattern",
+		},
+		"recursive directory error": {
+			cfg:     TemplateConfig{Pages: "missing/*.html", Recursive: true},
+			wantErr: "open missing: file does not exist",
+		},
+		"key collision": {
+			cfg:     TemplateConfig{Pages: "site/*/index.html"},
+			wantErr: `template: key "index.html" is used by both site/admin/index.html and site/public/index.html`,
+		},
+		"duplicate definition": {
+			cfg: TemplateConfig{
+				Pages:  "pages/*.html",
+				Shared: []string{"partials/*.html", "partials/dup/*.html"},
+				Funcs:  funcs,
+			},
+			wantErr: `template: "nav" is defined by both partials/nav.html and partials/dup/nav.html`,
+		},
+		"shared pattern matches no files": {
+			cfg:     TemplateConfig{Pages: "pages/*.html", Shared: []string{"components/*.html"}},
+			wantErr: "template: pattern matches no files: `components/*.html`",
+		},
+		"shared glob error": {
+			cfg:     TemplateConfig{Pages: "pages/*.html", Shared: []string{"["}},
+			wantErr: "syntax error in pattern",
+		},
+		"shared parse error": {
+			cfg:     TemplateConfig{Pages: "pages/*.html", Shared: []string{"broken/partials/*.html"}},
+			wantErr: "template: x.html:1: unclosed action",
+		},
+		"page parse error": {
+			cfg:     TemplateConfig{Pages: "broken/pages/*.html", Shared: []string{"layouts/*.html"}},
+			wantErr: "template: bad.html:1: unexpected \"}\" in define clause",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			templates, err := ParseTemplates(fsys, c.cfg)
+			if c.wantErr != "" {
+				if err == nil || err.Error() != c.wantErr {
+					t.Errorf(errorString, err, c.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(templates); got != len(c.wantKeys) {
+				t.Errorf(errorString, got, len(c.wantKeys))
+			}
+			for key, want := range c.wantKeys {
+				var b strings.Builder
+				if err := templates[key].ExecuteTemplate(&b, "base", "Sherlock"); err != nil {
+					t.Fatal(err)
+				}
+				if got := b.String(); got != want {
+					t.Errorf(errorString, got, want)
+				}
+			}
+		})
+	}
+}
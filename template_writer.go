@@ -1,17 +1,57 @@
 package reply
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"io/fs"
 	"net/http"
-	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Template writer implements Writer for template responses.
 type TemplateWriter struct {
 	Templates map[string]*template.Template
+
+	// BufferThreshold defines the size in bytes beyond which rendered output
+	// overflows its in-memory buffer as Overflow defines. If zero, output is
+	// always buffered. Options may override both per reply.
+	BufferThreshold int
+
+	// Overflow defines the handling of output beyond BufferThreshold. If
+	// zero, OverflowBuffer is used.
+	Overflow Overflow
+
+	// StreamErrorMarker defines text appended to a streamed reply whose
+	// template fails after streaming began, such as an HTML comment. If
+	// empty, the connection is aborted instead so that clients see an
+	// incomplete response.
+	StreamErrorMarker string
+
+	// PartialName defines an optional named template, such as "content",
+	// executed instead of Options.TemplateName for partial requests, those
+	// with a PartialHeader, so that they are replied to with a fragment of
+	// the page rather than its full layout. It is only executed instead of
+	// templates that render it, such as a layout, and not instead of other
+	// fragments or when Options.FullPage is set.
+	PartialName string
+
+	// PartialHeader defines the request header marking a partial request. If
+	// empty, DefaultPartialHeader is used.
+	PartialHeader string
+
+	// Localizer defines an optional Localizer of replies, which are rendered
+	// with the template variant of the locale of Options.Request. Templates
+	// calling T must be parsed with a T function, such as that of FuncMap.
+	// Templates are cloned for each locale, which html/template forbids once
+	// a template has executed, so the Localizer must be set before tw
+	// replies or renders. Validate does not execute tw's templates.
+	Localizer *Localizer
+
+	Body
 }
 
 // Options represents fields used in Reply.
@@ -27,43 +67,241 @@ type Options struct {
 
 	// Data defines data for use in a reply.
 	Data any
+
+	// Pagination defines optional pagination meta for a reply. A
+	// TemplateWriter executes its template with PageData, and a JSONWriter
+	// encodes Data alongside the meta.
+	Pagination *Pagination
+
+	// Fields defines an optional field-selection expression for JSON replies,
+	// such as "id,name,owner.email", typically taken from a request's
+	// "fields" query parameter.
+	Fields string
+
+	// Links defines optional hypermedia links by relation for writers that
+	// render them, such as a HALWriter. It is a pointer so that Options
+	// remains comparable.
+	Links *Links
+
+	// Request defines the request being replied to. It is optional, and used
+	// to answer conditional requests.
+	Request *http.Request
+
+	// LastModified defines an optional modification time of the reply's
+	// content, sent as Last-Modified and compared against If-Modified-Since.
+	LastModified time.Time
+
+	// Cache defines an optional Cache-Control policy for the reply,
+	// overriding the default policy of an Engine.
+	Cache *CacheControl
+
+	// HeadersOnly defines whether a reply to a HEAD Request skips rendering
+	// its body entirely and sends only headers. Headers derived from the
+	// body, such as Content-Length and ETag, are then omitted.
+	HeadersOnly bool
+
+	// BufferThreshold and Overflow override those of a TemplateWriter for
+	// the reply if not zero.
+	BufferThreshold int
+	Overflow        Overflow
+
+	// OOB defines optional comma-separated named templates a TemplateWriter
+	// executes after the reply's template, in order, such as "count,flash"
+	// for out-of-band swaps of htmx.
+	OOB string
+
+	// FullPage defines whether a TemplateWriter with a PartialName replies
+	// in full to partial requests.
+	FullPage bool
 }
 
 // Reply sends an HTTP status response header with the given status code and
 // writes an executed template to w using the opts provided. If an error occurs
-// at template execution, the function exits and does not write to w.
+// at template execution, the function exits and does not write to w, unless
+// the reply overflowed its buffer and began streaming.
 func (tw *TemplateWriter) Reply(w http.ResponseWriter, code int, opts Options) error {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.Header().Set("X-Content-Type-Options", "nosniff")
-	tmpl, ok := tw.Templates[opts.TemplateKey]
-	if !ok {
-		return fmt.Errorf("no such template '%s'", opts.TemplateKey)
-	}
-	buf := new(bytes.Buffer)
-	if opts.TemplateName != "" {
-		if err := tmpl.ExecuteTemplate(buf, opts.TemplateName, opts.Data); err != nil {
-			return err
+	tmpl, err := tw.lookup(opts)
+	if err != nil {
+		return err
+	}
+	if tw.hasPartial(tmpl, opts) {
+		addVary(w.Header(), tw.partialHeader())
+	}
+	if skipBody(opts) {
+		tw.sendHeaders(w, code, opts)
+		return nil
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	ow := &overflowWriter{
+		buf:       buf,
+		threshold: tw.BufferThreshold,
+		mode:      tw.Overflow,
+		stream: func() io.Writer {
+			w.WriteHeader(code)
+			return w
+		},
+	}
+	defer ow.close()
+	if opts.BufferThreshold != 0 {
+		ow.threshold = opts.BufferThreshold
+	}
+	if opts.Overflow != 0 {
+		ow.mode = opts.Overflow
+	}
+	err = tw.render(ow, tmpl, opts)
+	switch {
+	case err != nil && ow.streaming != nil:
+		return failStream(w, tw.StreamErrorMarker, err)
+	case err != nil:
+		return err
+	case ow.streaming != nil:
+		return nil
+	case ow.file != nil:
+		return tw.sendFile(w, code, ow.file, opts)
+	}
+	tw.send(w, code, buf, opts)
+	return nil
+}
+
+// Render writes tw's executed template to w using the opts provided, as Reply
+// does, for output other than a reply, such as an email. The template is
+// looked up and executed as in Reply, and if an error occurs, nothing is
+// written to w.
+func (tw *TemplateWriter) Render(w io.Writer, opts Options) error {
+	tmpl, err := tw.lookup(opts)
+	if err != nil {
+		return err
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := tw.render(buf, tmpl, opts); err != nil {
+		return err
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// RenderString returns tw's executed template using the opts provided, as
+// Render does.
+func (tw *TemplateWriter) RenderString(opts Options) (string, error) {
+	tmpl, err := tw.lookup(opts)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tw.render(&b, tmpl, opts); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// render executes tmpl, the template of opts, to w.
+func (tw *TemplateWriter) render(w io.Writer, tmpl *template.Template, opts Options) error {
+	data := opts.Data
+	if opts.Pagination != nil {
+		data = PageData{Data: opts.Data, Pagination: opts.Pagination}
+	}
+	return execute(w, tmpl, tw.partialName(tmpl, opts), opts.OOB, data)
+}
+
+// lookup returns the template of opts.TemplateKey, or its variant for the
+// locale of opts.Request if tw has a Localizer.
+func (tw *TemplateWriter) lookup(opts Options) (*template.Template, error) {
+	if tw.Localizer == nil {
+		tmpl, ok := tw.Templates[opts.TemplateKey]
+		if !ok {
+			return nil, fmt.Errorf("no such template '%s'", opts.TemplateKey)
 		}
-	} else {
-		if err := tmpl.Execute(buf, opts.Data); err != nil {
-			return err
+		return tmpl, nil
+	}
+	locale := tw.Localizer.Locale(opts.Request)
+	for _, key := range localizedKeys(opts.TemplateKey, locale) {
+		if tmpl, ok := tw.Templates[key]; ok {
+			return tw.Localizer.template(key, tmpl, locale)
 		}
 	}
-	w.WriteHeader(code)
-	_, _ = buf.WriteTo(w)
-	return nil
+	return nil, fmt.Errorf("no such template '%s'", opts.TemplateKey)
+}
+
+// ErrorData is the data an error template of a TemplateWriter is executed
+// with.
+type ErrorData struct {
+	// Code is the HTTP status code of the error.
+	Code int
+
+	// Status is the status text of Code, such as "Not Found".
+	Status string
+
+	// Message is the error message.
+	Message string
+
+	// Details are optional details of the error.
+	Details any
+
+	// Error is the error message, as in templates written before Message.
+	Error string
 }
 
 // Error sends an HTTP response header with the given status code and writes
-// tw's executed "error.html" template to w. It does not otherwise end the
-// request; the caller should ensure no further writes are done to w.
+// tw's executed error template to w. The template is the first of tw's
+// Templates found by the status code, such as "404.html", then by its class,
+// such as "4xx.html", then "error.html". If a template fails to execute, the
+// next is tried, and a built-in default last, so that an error reply is always
+// sent with code. It does not otherwise end the request; the caller should
+// ensure no further writes are done to w.
 func (tw *TemplateWriter) Error(w http.ResponseWriter, error string, code int) {
-	_ = tw.Reply(w, code, Options{
-		TemplateKey: "error.html",
-		Data:        struct{ Error string }{Error: error},
-	})
+	tw.ErrorDetails(w, nil, error, code, nil)
+}
+
+// ErrorDetails replies like Error, with details in the error template's
+// ErrorData. r is the optional request replied to, used as Options.Request,
+// so that the error template is the variant of its locale if tw has a
+// Localizer.
+func (tw *TemplateWriter) ErrorDetails(w http.ResponseWriter, r *http.Request, error string, code int, details any) {
+	data := ErrorData{
+		Code:    code,
+		Status:  http.StatusText(code),
+		Message: error,
+		Details: details,
+		Error:   error,
+	}
+	var se *StreamError
+	for _, key := range tw.errorTemplates(code) {
+		err := tw.Reply(w, code, Options{TemplateKey: key, Data: data, Request: r})
+		if err == nil || errors.As(err, &se) {
+			return
+		}
+	}
+	fallback := TemplateWriter{
+		Templates: map[string]*template.Template{"error.html": defaultErrorTemplate},
+		Body:      tw.Body,
+	}
+	_ = fallback.Reply(w, code, Options{TemplateKey: "error.html", Data: data, Request: r})
 }
 
+// errorTemplates returns the keys of tw's error templates for code, in the
+// order they are tried.
+func (tw *TemplateWriter) errorTemplates(code int) []string {
+	var keys []string
+	for _, key := range []string{
+		strconv.Itoa(code) + ".html",
+		strconv.Itoa(code/100) + "xx.html",
+		"error.html",
+	} {
+		if _, ok := tw.Templates[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// defaultErrorTemplate is the error template used when none of a
+// TemplateWriter's error templates execute.
+var defaultErrorTemplate = template.Must(template.New("error.html").Parse("<p>{{.Error}}</p>"))
+
 // NewTemplateWriter returns a new TemplateWriter with the given templates and
 // an empty buffer. If no "error.html" or "no_content.html" are supplied in
 // templates, defaults are parsed and used.
@@ -80,23 +318,12 @@ func NewTemplateWriter(templates map[string]*template.Template) *TemplateWriter
 }
 
 // TemplateMap returns a map of string to HTML template using fsys as its source.
+// It parses the files matching src as pages with the optional base file shared
+// by each; see ParseTemplates for several shared files.
 func TemplateMap(fsys fs.FS, src string, base string, funcs template.FuncMap) (map[string]*template.Template, error) {
-	sources, err := fs.Glob(fsys, src)
-	if err != nil {
-		return nil, err
-	}
-	cache := map[string]*template.Template{}
-	for _, s := range sources {
-		name := filepath.Base(s)
-		files := []string{s}
-		if base != "" {
-			files = append([]string{base}, files...)
-		}
-		tmpl, err := template.New(name).Funcs(funcs).ParseFS(fsys, files...)
-		if err != nil {
-			return nil, err
-		}
-		cache[name] = tmpl
+	cfg := TemplateConfig{Pages: src, Funcs: funcs}
+	if base != "" {
+		cfg.Shared = []string{base}
 	}
-	return cache, nil
+	return ParseTemplates(fsys, cfg)
 }
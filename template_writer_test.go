@@ -159,6 +159,51 @@ func TestTemplateReply(t *testing.T) {
 	}
 }
 
+func TestTemplateRender(t *testing.T) {
+	tw := NewTemplateWriter(map[string]*template.Template{"foo": foo, "baz": baz})
+	cases := map[string]struct {
+		opts    Options
+		want    string
+		wantErr bool
+	}{
+		"template": {
+			opts: Options{TemplateKey: "baz", Data: struct{ Name string }{Name: "Sherlock"}},
+			want: "Hiya, Sherlock",
+		},
+		"named template": {
+			opts: Options{TemplateKey: "foo", TemplateName: "base", Data: struct{ Name string }{Name: "Sherlock"}},
+			want: "Hello, Sherlock",
+		},
+		"no such template": {
+			opts:    Options{TemplateKey: "missing"},
+			wantErr: true,
+		},
+		"execution error": {
+			opts:    Options{TemplateKey: "baz", Data: struct{ Nom string }{Nom: "Sherlock"}},
+			wantErr: true,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var b strings.Builder
+			err := tw.Render(&b, c.opts)
+			if (err != nil) != c.wantErr {
+				t.Errorf(errorString, err, c.wantErr)
+			}
+			if got := b.String(); got != c.want {
+				t.Errorf(errorString, got, c.want)
+			}
+			got, err := tw.RenderString(c.opts)
+			if (err != nil) != c.wantErr {
+				t.Errorf(errorString, err, c.wantErr)
+			}
+			if got != c.want {
+				t.Errorf(errorString, got, c.want)
+			}
+		})
+	}
+}
+
 func TestTemplateError(t *testing.T) {
 	cases := map[string]int{
 		"bad Request": http.StatusBadRequest,
@@ -181,6 +226,120 @@ func TestTemplateError(t *testing.T) {
 	}
 }
 
+func TestTemplateErrorTemplates(t *testing.T) {
+	templates := map[string]*template.Template{
+		"404.html": template.Must(template.New("404.html").Parse(`missing: {{.Message}}`)),
+		"4xx.html": template.Must(template.New("4xx.html").Parse(`{{.Code}} {{.Status}}: {{.Message}}{{with .Details}} {{.}}{{end}}`)),
+		"500.html": template.Must(template.New("500.html").Parse(`oops: {{.Message}}`)),
+		"503.html": template.Must(template.New("503.html").Parse(`maintenance`)),
+	}
+	etw := Engine{Writer: NewTemplateWriter(templates)}
+	cases := map[string]struct {
+		reply    func(w http.ResponseWriter)
+		wantCode int
+		wantBody string
+	}{
+		"status template": {
+			reply:    etw.NotFound,
+			wantCode: http.StatusNotFound,
+			wantBody: "missing: Not Found",
+		},
+		"class template": {
+			reply:    etw.Forbidden,
+			wantCode: http.StatusForbidden,
+			wantBody: "403 Forbidden: Forbidden",
+		},
+		"class template with details": {
+			reply: func(w http.ResponseWriter) {
+				etw.ErrorDetails(w, "invalid form", http.StatusUnprocessableEntity, []string{"email is required"})
+			},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "422 Unprocessable Entity: invalid form [email is required]",
+		},
+		"status template of another class": {
+			reply:    etw.ServiceUnavailable,
+			wantCode: http.StatusServiceUnavailable,
+			wantBody: "maintenance",
+		},
+		"default error template": {
+			reply:    etw.BadGateway,
+			wantCode: http.StatusBadGateway,
+			wantBody: errorTemplateBody(http.StatusBadGateway),
+		},
+		"reply error falls back": {
+			reply: func(w http.ResponseWriter) {
+				etw.OK(w, Options{TemplateKey: "missing.html"})
+			},
+			wantCode: http.StatusInternalServerError,
+			wantBody: "oops: Internal Server Error",
+		},
+		"details unsupported by writer": {
+			reply: func(w http.ResponseWriter) {
+				Engine{Writer: JSONWriter{}}.ErrorDetails(w, "invalid form", http.StatusUnprocessableEntity, []string{"email is required"})
+			},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `{"error":"invalid form"}` + "\n",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c.reply(w)
+			if got := w.Code; got != c.wantCode {
+				t.Errorf(errorString, got, c.wantCode)
+			}
+			if got := w.Body.String(); got != c.wantBody {
+				t.Errorf(errorString, got, c.wantBody)
+			}
+		})
+	}
+}
+
+func TestTemplateErrorTemplateFallback(t *testing.T) {
+	parse := func(name, text string) *template.Template {
+		return template.Must(template.New(name).Parse(text))
+	}
+	cases := map[string]struct {
+		templates map[string]*template.Template
+		wantBody  string
+	}{
+		"status template fails": {
+			templates: map[string]*template.Template{
+				"404.html": parse("404.html", `{{.Missing.Field}}`),
+				"4xx.html": parse("4xx.html", `{{.Code}}: {{.Message}}`),
+			},
+			wantBody: "404: Not Found",
+		},
+		"class template fails": {
+			templates: map[string]*template.Template{
+				"404.html":   parse("404.html", `{{.Missing.Field}}`),
+				"4xx.html":   parse("4xx.html", `{{.Missing.Field}}`),
+				"error.html": parse("error.html", `error: {{.Message}}`),
+			},
+			wantBody: "error: Not Found",
+		},
+		"all templates fail": {
+			templates: map[string]*template.Template{
+				"404.html":   parse("404.html", `{{.Missing.Field}}`),
+				"error.html": parse("error.html", `{{.Missing.Field}}`),
+			},
+			wantBody: errorTemplateBody(http.StatusNotFound),
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			Engine{Writer: NewTemplateWriter(c.templates)}.NotFound(w)
+			if got := w.Code; got != http.StatusNotFound {
+				t.Errorf(errorString, got, http.StatusNotFound)
+			}
+			if got := w.Body.String(); got != c.wantBody {
+				t.Errorf(errorString, got, c.wantBody)
+			}
+		})
+	}
+}
+
 func TestTemplateMap(t *testing.T) {
 	templates := fstest.MapFS{
 		"html/base.html":        {Data: []byte(`{{define "base"}}Base here. {{template "main" .}}{{end}}`)},
@@ -239,3 +398,17 @@ func TestTemplateMap(t *testing.T) {
 		})
 	}
 }
+
+func BenchmarkTemplateReply(b *testing.B) {
+	list := template.Must(template.New("list").Parse(`<ul>{{range .}}<li>{{.}}</li>{{end}}</ul>`))
+	names := make([]string, 100)
+	for i := range names {
+		names[i] = "Sherlock"
+	}
+	tw := NewTemplateWriter(map[string]*template.Template{"list": list})
+	w := discardWriter{header: http.Header{}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = tw.Reply(w, http.StatusOK, Options{TemplateKey: "list", Data: names})
+	}
+}
@@ -0,0 +1,127 @@
+package reply
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+)
+
+// TemplateSample defines data to validate a template of a TemplateWriter with.
+type TemplateSample struct {
+	// Key defines a lookup in the TemplateWriter's Templates.
+	Key string
+
+	// Name defines an optional named template to execute, as in Options.
+	Name string
+
+	// Data defines sample data to execute the template with.
+	Data any
+
+	// Type defines the type of data the template is executed with, used if
+	// Data is nil. The template is executed with a zero value of Type in
+	// which pointers are allocated and slices have one element, so that
+	// nested fields are evaluated.
+	Type reflect.Type
+}
+
+// ValidationError is an error executing a template during validation.
+type ValidationError struct {
+	Key  string
+	Name string
+	Err  error
+}
+
+func (ve *ValidationError) Error() string {
+	if ve.Name == "" {
+		return fmt.Sprintf("template key '%s': %v", ve.Key, ve.Err)
+	}
+	return fmt.Sprintf("template key '%s', name '%s': %v", ve.Key, ve.Name, ve.Err)
+}
+
+func (ve *ValidationError) Unwrap() error {
+	return ve.Err
+}
+
+// Validate executes templates of tw against samples, discarding their output,
+// so that errors such as misspelled fields are found at startup or in tests
+// rather than when a page is requested. If no samples are given, every
+// template is executed with nil data. All errors are returned joined, each a
+// *ValidationError whose template error includes its position.
+//
+// Validate executes clones of tw's templates, since an html/template cannot be
+// cloned once executed, so that it may run before a Localizer is set. With a
+// Localizer, the clone of the default locale is executed.
+func (tw *TemplateWriter) Validate(samples ...TemplateSample) error {
+	if len(samples) == 0 {
+		for key := range tw.Templates {
+			samples = append(samples, TemplateSample{Key: key})
+		}
+		sort.Slice(samples, func(i, j int) bool { return samples[i].Key < samples[j].Key })
+	}
+	var errs []error
+	for _, s := range samples {
+		if err := tw.validate(s); err != nil {
+			errs = append(errs, &ValidationError{Key: s.Key, Name: s.Name, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validate executes the template of sample s.
+func (tw *TemplateWriter) validate(s TemplateSample) error {
+	tmpl, err := tw.lookup(Options{TemplateKey: s.Key})
+	if err != nil {
+		return err
+	}
+	if tw.Localizer == nil {
+		if clone, err := tmpl.Clone(); err == nil {
+			tmpl = clone
+		}
+	}
+	data := s.Data
+	if data == nil && s.Type != nil {
+		v := reflect.New(s.Type).Elem()
+		fillSample(v, map[reflect.Type]int{})
+		data = v.Interface()
+	}
+	if s.Name != "" {
+		return tmpl.ExecuteTemplate(io.Discard, s.Name, data)
+	}
+	return tmpl.Execute(io.Discard, data)
+}
+
+// fillSample allocates the pointers of v and gives its slices one element,
+// recursively. depth counts the pointer and slice types being filled, so that
+// recursive types are filled to a depth of two.
+func fillSample(v reflect.Value, depth map[reflect.Type]int) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Slice:
+		t := v.Type()
+		if depth[t] == 2 {
+			return
+		}
+		depth[t]++
+		defer func() { depth[t]-- }()
+		if v.Kind() == reflect.Pointer {
+			v.Set(reflect.New(t.Elem()))
+			fillSample(v.Elem(), depth)
+			return
+		}
+		v.Set(reflect.MakeSlice(t, 1, 1))
+		fillSample(v.Index(0), depth)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			fillSample(v.Index(i), depth)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Field(i); f.CanSet() {
+				fillSample(f, depth)
+			}
+		}
+	case reflect.Map:
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+}
@@ -0,0 +1,105 @@
+package reply
+
+import (
+	"errors"
+	"html/template"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type sampleUser struct {
+	Name    string
+	Friends []*sampleUser
+}
+
+type samplePage struct {
+	User  *sampleUser
+	Tags  map[string]string
+	Title string
+}
+
+func TestValidate(t *testing.T) {
+	templates := map[string]*template.Template{
+		"user.html":  template.Must(template.New("user.html").Parse(`{{.User.Name}}{{range .User.Friends}}{{.Name}}{{end}}{{.Tags.color}}`)),
+		"typo.html":  template.Must(template.New("typo.html").Parse(`{{.Usre.Name}}`)),
+		"named.html": template.Must(template.New("named.html").Parse(`{{define "main"}}{{.Title}}{{end}}`)),
+		"plain.html": template.Must(template.New("plain.html").Parse(`plain`)),
+	}
+	cases := map[string]struct {
+		samples  []TemplateSample
+		wantErrs []string
+	}{
+		"sample data": {
+			samples: []TemplateSample{
+				{Key: "user.html", Data: samplePage{User: &sampleUser{Name: "Sherlock"}}},
+				{Key: "named.html", Name: "main", Data: samplePage{}},
+			},
+		},
+		"sample type": {
+			samples: []TemplateSample{
+				{Key: "user.html", Type: reflect.TypeOf(samplePage{})},
+			},
+		},
+		"sample type; typo": {
+			samples: []TemplateSample{
+				{Key: "typo.html", Type: reflect.TypeOf(samplePage{})},
+			},
+			wantErrs: []string{
+				"template key 'typo.html': template: typo.html:1:7: executing \"typo.html\" at <.Usre.Name>: can't evaluate field Usre in type reply.samplePage",
+			},
+		},
+		"zero value without allocation": {
+			samples: []TemplateSample{
+				{Key: "user.html", Data: samplePage{}},
+			},
+			wantErrs: []string{"template key 'user.html': template: user.html:1:7: executing \"user.html\" at <.User.Name>: nil pointer evaluating *reply.sampleUser.Name"},
+		},
+		"all errors reported": {
+			samples: []TemplateSample{
+				{Key: "typo.html", Type: reflect.TypeOf(samplePage{})},
+				{Key: "missing.html"},
+				{Key: "named.html", Name: "footer"},
+			},
+			wantErrs: []string{
+				"template key 'typo.html': template: typo.html:1:7: executing \"typo.html\" at <.Usre.Name>: can't evaluate field Usre in type reply.samplePage",
+				"template key 'missing.html': no such template 'missing.html'",
+				"template key 'named.html', name 'footer': html/template: \"footer\" is undefined",
+			},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			tw := &TemplateWriter{Templates: templates}
+			err := tw.Validate(c.samples...)
+			got := []string{}
+			if err != nil {
+				got = strings.Split(err.Error(), "\n")
+			}
+			if len(got) != len(c.wantErrs) {
+				t.Fatalf(errorString, got, c.wantErrs)
+			}
+			for i := range got {
+				if got[i] != c.wantErrs[i] {
+					t.Errorf(errorString, got[i], c.wantErrs[i])
+				}
+			}
+			var ve *ValidationError
+			if err != nil && !errors.As(err, &ve) {
+				t.Errorf(errorString, err, ve)
+			}
+		})
+	}
+}
+
+func TestValidateAll(t *testing.T) {
+	tw := &TemplateWriter{Templates: map[string]*template.Template{
+		"a.html": template.Must(template.New("a.html").Parse(`a`)),
+		"b.html": template.Must(template.New("b.html").Parse(`{{template "missing"}}`)),
+	}}
+	err := tw.Validate()
+	want := "template key 'b.html': html/template:b.html:1:11: no such template \"missing\""
+	if err == nil || err.Error() != want {
+		t.Errorf(errorString, err, want)
+	}
+}